@@ -0,0 +1,182 @@
+package poet
+
+import (
+	"bytes"
+	"strings"
+)
+
+// AnonymousStruct is a struct type literal, like struct{ Name string }. It is a TypeReference,
+// so it is written inline wherever it is used as a type.
+type AnonymousStruct struct {
+	Fields []IdentifierField // Fields without a name are embedded
+}
+
+var _ TypeReference = (*AnonymousStruct)(nil)
+
+// NewAnonymousStruct returns an empty struct type literal, struct{}.
+func NewAnonymousStruct() *AnonymousStruct {
+	return &AnonymousStruct{}
+}
+
+// Field adds a field to the struct.
+func (s *AnonymousStruct) Field(name string, typeRef TypeReference) *AnonymousStruct {
+	return s.FieldWithTag(name, typeRef, "")
+}
+
+// FieldWithTag adds a field to the struct with a tag on the field.
+func (s *AnonymousStruct) FieldWithTag(name string, typeRef TypeReference, tag string) *AnonymousStruct {
+	s.Fields = append(s.Fields, IdentifierField{
+		Identifier: Identifier{
+			Name: name,
+			Type: typeRef,
+		},
+		Tag: tag,
+	})
+	return s
+}
+
+// GetImports returns the imports used by the struct's fields.
+func (s *AnonymousStruct) GetImports() []Import {
+	imports := []Import{}
+	for _, f := range s.Fields {
+		imports = append(imports, getImports(f.Type)...)
+	}
+	return imports
+}
+
+func (s *AnonymousStruct) elems() []TypeReference {
+	types := make([]TypeReference, len(s.Fields))
+	for i, f := range s.Fields {
+		types[i] = f.Type
+	}
+	return types
+}
+
+// GetName returns the struct type literal.
+func (s *AnonymousStruct) GetName() string {
+	return s.getNameIn(nil)
+}
+
+func (s *AnonymousStruct) getNameIn(names *importNames) string {
+	fields := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		field := typeName(f.Type, names)
+		if !f.IsEmbedded() {
+			field = f.Name + " " + field
+		}
+		if f.Tag != "" {
+			field += " `" + f.Tag + "`"
+		}
+		fields[i] = field
+	}
+
+	return literalBody("struct", fields)
+}
+
+// AnonymousInterface is an interface type literal, like interface{ Close() error }. It is a
+// TypeReference, so it is written inline wherever it is used as a type.
+type AnonymousInterface struct {
+	EmbeddedInterfaces []TypeReference
+	Unions             [][]TypeReference
+	Methods            []*FuncSpec
+}
+
+var _ TypeReference = (*AnonymousInterface)(nil)
+
+// NewAnonymousInterface returns an empty interface type literal, interface{}.
+func NewAnonymousInterface() *AnonymousInterface {
+	return &AnonymousInterface{}
+}
+
+// Method adds a method to the interface. Only the method's name and signature are used.
+func (i *AnonymousInterface) Method(spec *FuncSpec) *AnonymousInterface {
+	i.Methods = append(i.Methods, spec)
+	return i
+}
+
+// EmbedInterface specifies an interface to embed in the interface.
+func (i *AnonymousInterface) EmbedInterface(interfaceType TypeReference) *AnonymousInterface {
+	i.EmbeddedInterfaces = append(i.EmbeddedInterfaces, interfaceType)
+	return i
+}
+
+// Union adds a type set element to the interface, as for InterfaceSpec.
+func (i *AnonymousInterface) Union(terms ...TypeReference) *AnonymousInterface {
+	i.Unions = append(i.Unions, terms)
+	return i
+}
+
+// GetImports returns the imports used by the interface's elements.
+func (i *AnonymousInterface) GetImports() []Import {
+	imports := []Import{}
+	for _, embedded := range i.EmbeddedInterfaces {
+		imports = append(imports, getImports(embedded)...)
+	}
+	for _, union := range i.Unions {
+		for _, term := range union {
+			imports = append(imports, getImports(term)...)
+		}
+	}
+	for _, method := range i.Methods {
+		imports = append(imports, method.GetImports()...)
+	}
+	return imports
+}
+
+func (i *AnonymousInterface) elems() []TypeReference {
+	types := append([]TypeReference{}, i.EmbeddedInterfaces...)
+	for _, union := range i.Unions {
+		types = append(types, union...)
+	}
+	for _, method := range i.Methods {
+		types = append(types, parameterTypes(method.Parameters)...)
+		types = append(types, parameterTypes(method.ResultParameters)...)
+	}
+	return types
+}
+
+// GetName returns the interface type literal.
+func (i *AnonymousInterface) GetName() string {
+	return i.getNameIn(nil)
+}
+
+func (i *AnonymousInterface) getNameIn(names *importNames) string {
+	elems := []string{}
+
+	for _, embedded := range i.EmbeddedInterfaces {
+		elems = append(elems, typeName(embedded, names))
+	}
+	for _, union := range i.Unions {
+		if len(union) == 0 {
+			continue
+		}
+		terms := make([]string, len(union))
+		for n, term := range union {
+			terms[n] = typeName(term, names)
+		}
+		elems = append(elems, strings.Join(terms, " | "))
+	}
+	for _, method := range i.Methods {
+		signature := &typeReferenceFuncType{
+			Params:  method.Parameters,
+			Results: method.ResultParameters,
+		}
+		elems = append(elems, method.Name+strings.TrimPrefix(signature.getNameIn(names), "func"))
+	}
+
+	return literalBody("interface", elems)
+}
+
+// literalBody writes a struct or interface type literal on one line, the way gofmt does.
+func literalBody(keyword string, elems []string) string {
+	if len(elems) == 0 {
+		return keyword + "{}"
+	}
+
+	b := bytes.Buffer{}
+	b.WriteString(keyword)
+	b.WriteString("{ ")
+	b.WriteString(strings.Join(elems, "; "))
+	b.WriteString(" }")
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package poet
+
+import (
+	"bytes"
+	"go/token"
+	"go/types"
+	"io"
+	"reflect"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type AnonymousSuite struct{}
+
+var _ = Suite(&AnonymousSuite{})
+
+func (s *AnonymousSuite) TestAnonymousStruct(c *C) {
+	c.Assert(NewAnonymousStruct().GetName(), Equals, "struct{}")
+
+	st := NewAnonymousStruct().
+		Field("Timeout", TypeReferenceFromInstance(time.Duration(0))).
+		FieldWithTag("Name", String, `json:"name"`).
+		Field("", TypeReferenceFromInstance(&bytes.Buffer{}))
+
+	c.Assert(st.GetName(), Equals, "struct{ Timeout time.Duration; Name string `json:\"name\"`; *bytes.Buffer }")
+	c.Assert(st.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "time", Qualified: true},
+		(*ImportSpec)(nil),
+		&ImportSpec{Package: "bytes", Qualified: true},
+	})
+}
+
+func (s *AnonymousSuite) TestAnonymousInterface(c *C) {
+	c.Assert(NewAnonymousInterface().GetName(), Equals, "interface{}")
+
+	i := NewAnonymousInterface().
+		EmbedInterface(TypeReferenceFromInstance((*io.Reader)(nil))).
+		Method(NewFuncSpec("Close").ResultParameter("", Error)).
+		Method(NewFuncSpec("Printf").
+			Parameter("format", String).
+			VariadicParameter("args", NewAnonymousInterface()))
+
+	c.Assert(i.GetName(), Equals, "interface{ io.Reader; Close() error; Printf(format string, args ...interface{}) }")
+	c.Assert(i.GetImports()[0], DeepEquals, &ImportSpec{Package: "io", Qualified: true})
+
+	c.Assert(NewAnonymousInterface().Union(Approx(Int), String).GetName(), Equals, "interface{ ~int | string }")
+	c.Assert(NewAnonymousInterface().Union().GetName(), Equals, "interface{}")
+}
+
+func (s *AnonymousSuite) TestAnonymousFromInstance(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{struct{}{}, "struct{}"},
+		{&struct{ A int }{}, "*struct{ A int }"},
+		{[]struct {
+			B *bytes.Buffer `json:"b"`
+			time.Time
+		}{}, "[]struct{ B *bytes.Buffer `json:\"b\"`; time.Time }"},
+		{map[string]struct{}{}, "map[string]struct{}"},
+		{(*interface{ Close() error })(nil), "interface{ Close() error }"},
+		{[]interface {
+			io.Writer
+			Len() int
+		}{}, "[]interface{ Len() int; Write([]uint8) (int, error) }"},
+	}
+
+	for _, v := range values {
+		c.Check(TypeReferenceFromInstance(v.value).GetName(), Equals, v.expected)
+	}
+}
+
+func (s *AnonymousSuite) TestAnonymousFromGoType(c *C) {
+	pkg := types.NewPackage("example.com/client", "client")
+	client := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Client", nil), types.NewStruct(nil, nil), nil)
+	closer := types.NewFunc(token.NoPos, nil, "Close", types.NewSignatureType(nil, nil, nil, nil,
+		types.NewTuple(types.NewParam(token.NoPos, nil, "", types.Universe.Lookup("error").Type())), false))
+
+	st := types.NewStruct([]*types.Var{
+		types.NewField(token.NoPos, nil, "Client", types.NewPointer(client), true),
+		types.NewField(token.NoPos, nil, "Retries", types.Typ[types.Int], false),
+	}, []string{"", `yaml:"retries"`})
+	iface := types.NewInterfaceType([]*types.Func{closer}, []types.Type{
+		types.NewUnion([]*types.Term{types.NewTerm(true, types.Typ[types.Int]), types.NewTerm(false, client)}),
+	})
+
+	c.Assert(TypeReferenceFromGoType(st).GetName(), Equals, "struct{ *client.Client; Retries int `yaml:\"retries\"` }")
+	c.Assert(TypeReferenceFromGoType(iface).GetName(), Equals, "interface{ ~int | client.Client; Close() error }")
+	c.Assert(TypeReferenceFromGoType(st).GetImports()[0], DeepEquals, &ImportSpec{Package: "example.com/client", Qualified: true})
+}
+
+func (s *AnonymousSuite) TestAnonymousInFile(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"io\"\n" +
+		"\t\"time\"\n" +
+		")\n" +
+		"\n" +
+		"type server struct {\n" +
+		"\tconfig struct{ Timeout time.Duration }\n" +
+		"\tconn   interface{ io.Closer }\n" +
+		"\tdone   chan struct{}\n" +
+		"}\n"
+
+	st := NewStructSpec("server").
+		Field("config", NewAnonymousStruct().Field("Timeout", TypeReferenceFromInstance(time.Duration(0)))).
+		Field("conn", NewAnonymousInterface().EmbedInterface(TypeReferenceFromInstance((*io.Closer)(nil)))).
+		Field("done", ChanOf(reflect.BothDir, NewAnonymousStruct()))
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(st)
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
@@ -2,6 +2,10 @@
 // reusable way.
 package poet
 
+import (
+	"strings"
+)
+
 // CodeBlock represent a block of code that can be included in a File
 type CodeBlock interface {
 	// String is the literal string serialization of the code.
@@ -16,6 +20,10 @@ type Statement struct {
 	Arguments    []interface{} // Arguments are used within the format string
 	BeforeIndent int           // BeforeIndent augments the indent for the current statement.
 	AfterIndent  int           // AfterIndent specifies indentation for subsequent statements.
+
+	generated  bool   // generated is true for statements a spec writes itself, like a function's signature
+	section    string // section is set on the first statement of a spec nested in another, like an attached method
+	sectionEnd bool   // sectionEnd is set on the last statement of a nested spec
 }
 
 // Identifier represent an instance of a variable
@@ -30,10 +38,31 @@ type IdentifierParameter struct {
 	Variadic bool // Variadic specifies whether the parameter is a variadic
 }
 
-// IdentifierField represent a field in a struct
+// IdentifierField represent a field in a struct. A field without a name is embedded.
 type IdentifierField struct {
 	Identifier
-	Tag string // Tag is a struct field tag, e.g. `json:"foo"`
+	Tag         string // Tag is a struct field tag, e.g. `json:"foo"`
+	Comment     string // Comment is written above the field
+	LineComment string // LineComment is written at the end of the field's line
+}
+
+// IsEmbedded reports whether the field is an embedded field, like sync.Mutex or *bytes.Buffer.
+func (f IdentifierField) IsEmbedded() bool {
+	return f.Name == ""
+}
+
+// FieldName returns the name the field is accessed by. An embedded field is named after its
+// type without the pointer, package or type arguments, e.g. Buffer for *bytes.Buffer.
+func (f IdentifierField) FieldName() string {
+	if !f.IsEmbedded() || f.Type == nil {
+		return f.Name
+	}
+
+	name := strings.TrimPrefix(f.Type.GetName(), "*")
+	if ndx := strings.Index(name, "["); ndx >= 0 {
+		name = name[:ndx]
+	}
+	return name[strings.LastIndex(name, ".")+1:]
 }
 
 // Import represent an individual imported package.
@@ -0,0 +1,77 @@
+package poet
+
+// Code is a reusable sequence of statements that carries its own imports. Code can be added
+// to the body of functions and methods, used as a $C argument to nest it within a statement
+// or a variable's value, or added to a file on its own.
+type Code struct {
+	Statements []Statement
+}
+
+var _ CodeBlock = (*Code)(nil)
+
+// NewCode returns Code made up of the given statements.
+func NewCode(statements ...Statement) *Code {
+	return &Code{
+		Statements: statements,
+	}
+}
+
+// Statement is a convenient method to append a statement to the code
+func (c *Code) Statement(format string, args ...interface{}) *Code {
+	c.Statements = append(c.Statements, newStatement(0, 0, format, args...))
+	return c
+}
+
+// AddStatements appends statements to the code, such as those from a CodeBlockBuilder.
+func (c *Code) AddStatements(statements ...Statement) *Code {
+	c.Statements = append(c.Statements, statements...)
+	return c
+}
+
+// GetImports returns the imports used by the arguments of the code's statements.
+func (c *Code) GetImports() []Import {
+	return statementImports(c.Statements)
+}
+
+// GetStatements returns the code's statements.
+func (c *Code) GetStatements() []Statement {
+	return c.Statements
+}
+
+func (c *Code) String() string {
+	return mustWriteCodeBlock(c)
+}
+
+// Validate returns an ErrorList of the code's statements that cannot be written, or nil.
+func (c *Code) Validate() error {
+	_, err := writeCodeBlock(c)
+	return err
+}
+
+// statementImports returns the imports needed by the arguments of the statements, which
+// includes TypeReferences, nested code and the types named by $V literals.
+func statementImports(statements []Statement) []Import {
+	imports := []Import{}
+
+	for _, st := range statements {
+		verbs := templateVerbs(st.Format)
+		for i, arg := range st.Arguments {
+			if i < len(verbs) && verbs[i] == 'V' {
+				imports = append(imports, literalImports(arg)...)
+			} else if typeRef, ok := arg.(TypeReference); ok && i < len(verbs) && verbs[i] == 'T' {
+				// a spec used as a type only needs its package, not the imports of its declaration
+				imports = append(imports, getImports(typeRef)...)
+			} else if asImporter, ok := arg.(importer); ok {
+				imports = append(imports, asImporter.GetImports()...)
+			}
+		}
+	}
+
+	return imports
+}
+
+// importer is implemented by statement arguments that need imports, such as TypeReferences
+// and CodeBlocks.
+type importer interface {
+	GetImports() []Import
+}
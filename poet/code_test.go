@@ -0,0 +1,157 @@
+package poet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type CodeSuite struct{}
+
+var _ = Suite(&CodeSuite{})
+
+func (s *CodeSuite) TestCode(c *C) {
+	expected := "" +
+		"b := &bytes.Buffer{}\n" +
+		"fmt.Fprintln(b, x)\n"
+
+	code := NewCode().
+		Statement("b := &$T{}", TypeReferenceFromInstance(bytes.Buffer{})).
+		Statement("$T(b, x)", TypeReferenceFromInstance(fmt.Fprintln))
+
+	c.Assert(code.String(), Equals, expected)
+	c.Assert(code.Validate(), IsNil)
+	c.Assert(code.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "bytes", Qualified: true},
+		&ImportSpec{Package: "fmt", Qualified: true},
+	})
+}
+
+func (s *CodeSuite) TestCodeInFunctions(c *C) {
+	expected := "" +
+		"func (f *foo) bar() {\n" +
+		"\tfmt.Println()\n" +
+		"}\n"
+
+	code := NewCode(newStatement(0, 0, "$T()", TypeReferenceFromInstance(fmt.Println)))
+	m := NewMethodSpec("bar", "f", PointerTo(NewStructSpec("foo")))
+	m.AddCode(code)
+
+	c.Assert(m.String(), Equals, expected)
+	c.Assert(m.GetImports(), DeepEquals, code.GetImports())
+	c.Assert(NewFuncSpec("baz").AddCode(code).GetImports(), DeepEquals, code.GetImports())
+}
+
+func (s *CodeSuite) TestCodeNested(c *C) {
+	expected := "" +
+		"func foo() {\n" +
+		"\tif ok {\n" +
+		"\t\tdefer func () {\n" +
+		"\t\t\tif r := recover(); r != nil {\n" +
+		"\t\t\t\tfmt.Println(r)\n" +
+		"\t\t\t}\n" +
+		"\n" +
+		"\t\t\treturn\n" +
+		"\t\t}()\n" +
+		"\t\tif r := recover(); r != nil {\n" +
+		"\t\t\tfmt.Println(r)\n" +
+		"\t\t}\n" +
+		"\n" +
+		"\t\treturn\n" +
+		"\t}\n" +
+		"}\n"
+
+	recovery := NewCodeBlockBuilder().
+		If("r := recover(); r != nil").
+		Statement("$T(r)", TypeReferenceFromInstance(fmt.Println)).
+		End().
+		Statement("").
+		Statement("return").
+		Build()
+	fnc := NewFuncSpec("foo").
+		BlockStart("if ok").
+		Statement("defer $C()", NewFuncSpec("").AddCode(recovery)).
+		Statement("$C", recovery).
+		BlockEnd()
+
+	c.Assert(fnc.String(), Equals, expected)
+	c.Assert(fnc.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "fmt", Qualified: true},
+		&ImportSpec{Package: "fmt", Qualified: true},
+	})
+}
+
+func (s *CodeSuite) TestMultiLineLiteralsAreNotIndented(c *C) {
+	expected := "" +
+		"func foo() {\n" +
+		"\tif ok {\n" +
+		"\t\tx := `a\n" +
+		"b`\n" +
+		"\t\ty := \"c\\nd\"\n" +
+		"\t\tdefer func () {\n" +
+		"\t\t\tz := `e\n" +
+		"f`\n" +
+		"\t\t}()\n" +
+		"\t}\n" +
+		"}\n"
+
+	inner := NewFuncSpec("").Statement("z := $L", "`e\nf`")
+	fnc := NewFuncSpec("foo").
+		BlockStart("if ok").
+		Statement("x := $L", "`a\nb`").
+		Statement("y := $S", "c\nd").
+		Statement("defer $C()", inner).
+		BlockEnd()
+
+	c.Assert(fnc.String(), Equals, expected)
+}
+
+func (s *CodeSuite) TestCodeInVariable(c *C) {
+	expected := "" +
+		"var r io.Reader = func () io.Reader {\n" +
+		"\treturn &bytes.Buffer{}\n" +
+		"}()\n"
+
+	fnc := NewFuncSpec("").
+		ResultParameter("", TypeReferenceFromInstance((*io.Reader)(nil))).
+		Statement("return &$T{}", TypeReferenceFromInstance(bytes.Buffer{}))
+	v := &Variable{
+		Identifier: Identifier{
+			Name: "r",
+			Type: TypeReferenceFromInstance((*io.Reader)(nil)),
+		},
+		Value: newStatement(0, 0, "$C()", fnc),
+	}
+
+	c.Assert(v.String(), Equals, expected)
+	c.Assert(v.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "io", Qualified: true},
+		&ImportSpec{Package: "bytes", Qualified: true},
+		&ImportSpec{Package: "io", Qualified: true},
+	})
+}
+
+func (s *CodeSuite) TestCodeInFile(c *C) {
+	code := NewCode().Statement("var b = new($T)", TypeReferenceFromInstance(bytes.Buffer{}))
+	file := NewFileSpec("foo")
+	file.GlobalVariable("a", TypeReferenceFromInstance((*io.Reader)(nil)), "$C", code)
+
+	actual := file.String()
+	c.Assert(strings.Contains(actual, "\t\"bytes\"\n"), Equals, true)
+	c.Assert(strings.Contains(actual, "\t\"io\"\n"), Equals, true)
+}
+
+func (s *CodeSuite) TestCodeWithInvalidArgument(c *C) {
+	fnc := NewFuncSpec("foo").Statement("$C", "return")
+
+	c.Assert(fnc.Validate(), ErrorMatches, `func foo: statement 0 \("\$C"\): \$C must have statements, got type=string "return"`)
+}
+
+func (s *CodeSuite) TestCodeWithInvalidStatement(c *C) {
+	fnc := NewFuncSpec("foo").Statement("$C", NewCode().Statement("$T", 1))
+
+	c.Assert(fnc.Validate(), ErrorMatches, `func foo: statement 0 \("\$C"\): \$T must implement TypeReference, got type=int 1`)
+}
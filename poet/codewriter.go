@@ -9,6 +9,28 @@ import (
 type codeWriter struct {
 	buffer        bytes.Buffer
 	currentIndent int
+	names         *importNames // names are the package names used for $T, or nil outside of a file
+	sections      []codeSection
+	nested        []nestedSection // nested are the sections containing the current nested section
+	statement     int             // statement is the index of the next user statement within the current section
+	errs          []error
+}
+
+// codeSection marks the first line of the code written for a spec.
+type codeSection struct {
+	line int
+	spec string
+}
+
+// nestedSection is a section that a nested spec was written in, to return to at its end.
+type nestedSection struct {
+	spec      string
+	statement int
+}
+
+// statementBlock is implemented by CodeBlocks that are made up of Statements.
+type statementBlock interface {
+	GetStatements() []Statement
 }
 
 // newCodeWriter constructs a new codeWriter
@@ -18,23 +40,133 @@ func newCodeWriter() *codeWriter {
 	}
 }
 
-// WriteCode writes code at the given indentation
+// WriteCode writes code at the given indentation. Each line of the code is indented, apart
+// from empty lines.
 func (c *codeWriter) WriteCode(code string) {
-	c.buffer.WriteString(strings.Repeat("\t", c.currentIndent))
-	c.buffer.WriteString(code)
+	indent := strings.Repeat("\t", c.currentIndent)
+	for _, line := range strings.SplitAfter(code, "\n") {
+		if line != "" && line != "\n" {
+			c.buffer.WriteString(indent)
+		}
+		c.buffer.WriteString(line)
+	}
 }
 
-// WriteCodeBlock writes a code block at the given indentation
+// WriteCodeBlock writes a code block at the given indentation. Blocks made up of Statements
+// are written statement by statement, so that their types are named for the writer's imports.
 func (c *codeWriter) WriteCodeBlock(block CodeBlock) {
+	if b, ok := block.(statementBlock); ok {
+		for _, s := range b.GetStatements() {
+			c.WriteStatement(s)
+		}
+		return
+	}
 	c.WriteCode(block.String())
 }
 
 // WriteStatement writes a new line of code with the current indentation and augments
 // the indentation per the statement. A newline is appended at the end of the statement.
+// Empty statements are written as a blank line without indentation. A statement that cannot
+// be templated is recorded as an error and written as a blank line.
 func (c *codeWriter) WriteStatement(s Statement) {
+	if s.section != "" {
+		c.beginNestedSection(s.section)
+	}
+
 	c.currentIndent += s.BeforeIndent
-	c.WriteCode(template(s.Format, s.Arguments...) + "\n")
+	code, err := templateIndented(c.names, c.currentIndent, s.Format, s.Arguments...)
+	if err != nil {
+		index := c.statement
+		format := s.Format
+		if s.generated {
+			index = -1
+			// the error names the part of the declaration that is wrong, like a parameter,
+			// while the format is written by the spec rather than the user
+			if argumentError(s.Arguments) != nil {
+				format = ""
+			}
+		}
+		c.errs = append(c.errs, &SpecError{
+			Spec:      c.currentSection(),
+			Statement: index,
+			Format:    format,
+			Err:       err,
+		})
+	} else if code != "" {
+		// only the first line is indented here, since templating indents the lines of nested
+		// code and any other line breaks are part of the code, like those in raw strings
+		c.buffer.WriteString(strings.Repeat("\t", c.currentIndent))
+		c.buffer.WriteString(code)
+	}
+	c.buffer.WriteString("\n")
 	c.currentIndent += s.AfterIndent
+	if !s.generated {
+		c.statement++
+	}
+
+	if s.sectionEnd {
+		c.endNestedSection()
+	}
+}
+
+// BeginSection records that the code written next is produced by the given spec.
+func (c *codeWriter) BeginSection(spec string) {
+	c.sections = append(c.sections, codeSection{line: c.line(), spec: spec})
+	c.nested = nil
+	c.statement = 0
+}
+
+// beginNestedSection records that the code written next is produced by a spec within the
+// current one, until the nested section ends.
+func (c *codeWriter) beginNestedSection(spec string) {
+	c.nested = append(c.nested, nestedSection{spec: c.currentSection(), statement: c.statement})
+	c.sections = append(c.sections, codeSection{line: c.line(), spec: spec})
+	c.statement = 0
+}
+
+// endNestedSection returns to the section that contains the current nested section.
+func (c *codeWriter) endNestedSection() {
+	if len(c.nested) == 0 {
+		return
+	}
+
+	last := c.nested[len(c.nested)-1]
+	c.nested = c.nested[:len(c.nested)-1]
+	c.sections = append(c.sections, codeSection{line: c.line(), spec: last.spec})
+	c.statement = last.statement
+}
+
+func (c *codeWriter) currentSection() string {
+	if len(c.sections) == 0 {
+		return ""
+	}
+	return c.sections[len(c.sections)-1].spec
+}
+
+// Err returns an ErrorList of the errors from the statements written so far, or nil.
+func (c *codeWriter) Err() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return ErrorList(c.errs)
+}
+
+// SectionAt returns the spec that produced the given line, or the empty string if no
+// section was started before the line.
+func (c *codeWriter) SectionAt(line int) string {
+	spec := ""
+	for _, s := range c.sections {
+		if s.line > line {
+			break
+		}
+		spec = s.spec
+	}
+	return spec
+}
+
+// line returns the number of the line that code is written to next, starting at 1.
+func (c *codeWriter) line() int {
+	return bytes.Count(c.buffer.Bytes(), []byte("\n")) + 1
 }
 
 // String gives a string with the code
@@ -42,6 +174,25 @@ func (c *codeWriter) String() string {
 	return c.buffer.String()
 }
 
+// writeCodeBlock writes a code block on its own, returning the code and an ErrorList of
+// the block's statements that could not be written.
+func writeCodeBlock(block CodeBlock) (string, error) {
+	w := newCodeWriter()
+	w.BeginSection(describeCodeBlock(block))
+	w.WriteCodeBlock(block)
+	return w.String(), w.Err()
+}
+
+// mustWriteCodeBlock writes a code block on its own, panicking if any of its statements
+// cannot be written.
+func mustWriteCodeBlock(block CodeBlock) string {
+	code, err := writeCodeBlock(block)
+	if err != nil {
+		panic(err.Error())
+	}
+	return code
+}
+
 func newStatement(beforeIndent, afterIndent int, format string, args ...interface{}) Statement {
 	return Statement{
 		BeforeIndent: beforeIndent,
@@ -59,5 +210,31 @@ func appendStatements(first, second Statement) Statement {
 		AfterIndent:  second.AfterIndent,
 		Format:       first.Format + second.Format,
 		Arguments:    args,
+		generated:    first.generated,
 	}
 }
+
+// generated marks statements that a spec writes itself, like a function's signature, so that
+// the statements of a SpecError are only counted among the statements given by the user.
+func generated(statements ...Statement) []Statement {
+	marked := make([]Statement, len(statements))
+	for i, s := range statements {
+		s.generated = true
+		marked[i] = s
+	}
+	return marked
+}
+
+// nested marks the statements of a spec written within another spec, like a method attached
+// to a struct, so that errors in them are attributed to the nested spec.
+func nested(blk CodeBlock, statements []Statement) []Statement {
+	if len(statements) == 0 {
+		return nil
+	}
+
+	marked := make([]Statement, len(statements))
+	copy(marked, statements)
+	marked[0].section = describeCodeBlock(blk)
+	marked[len(marked)-1].sectionEnd = true
+	return marked
+}
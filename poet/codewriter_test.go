@@ -149,3 +149,29 @@ func (f *CodeWriterSuite) TestCodeWriterAppendStatementsEmptySecond(c *C) {
 
 	c.Assert(actual, DeepEquals, expected)
 }
+
+func (f *CodeWriterSuite) TestCodeWriterSections(c *C) {
+	writer := newCodeWriter()
+	c.Assert(writer.SectionAt(1), Equals, "")
+
+	writer.BeginSection("first")
+	writer.WriteStatement(newStatement(0, 0, "a"))
+	writer.WriteStatement(newStatement(0, 0, "b"))
+	writer.BeginSection("second")
+	writer.WriteCodeBlock(NewStructSpec("foo"))
+
+	c.Check(writer.SectionAt(1), Equals, "first")
+	c.Check(writer.SectionAt(2), Equals, "first")
+	c.Check(writer.SectionAt(3), Equals, "second")
+	c.Check(writer.SectionAt(4), Equals, "second")
+}
+
+func (f *CodeWriterSuite) TestCodeWriterMultilineCode(c *C) {
+	expected := "\t\ta\n" +
+		"\n" +
+		"\t\tb\n"
+	writer := &codeWriter{currentIndent: 2}
+	writer.WriteCode("a\n\nb\n")
+
+	c.Assert(writer.String(), Equals, expected)
+}
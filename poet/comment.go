@@ -39,5 +39,16 @@ func (c Comment) GetStatements() []Statement {
 			statements[i] = newStatement(0, 0, "//")
 		}
 	}
-	return statements
+	return generated(statements...)
+}
+
+// withLineComment returns the statement with the comment written at the end of its line, e.g.
+// "Name string // the user's name". A line comment can't span lines, so the comment's lines are
+// joined with spaces.
+func withLineComment(s Statement, comment string) Statement {
+	if comment == "" {
+		return s
+	}
+
+	return appendStatements(s, newStatement(0, 0, " // $L", strings.Join(strings.Split(comment, "\n"), " ")))
 }
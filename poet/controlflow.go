@@ -0,0 +1,229 @@
+package poet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CodeBlockBuilder builds the statements of a function body, keeping track of the indentation
+// of control flow blocks. Format strings use the same templating as Statement.
+//
+//	b := NewCodeBlockBuilder().
+//		Range("i, v", "$L", "values").
+//		If("v == nil").
+//		Statement("continue").
+//		End().
+//		Statement("$T(i, v)", poet.TypeReferenceFromInstance(fmt.Println)).
+//		End()
+//
+// Else, ElseIf, Case, Default and End must follow a block they belong to, and every block must
+// be ended with End. Statements that don't, and blocks that are left open, are reported as
+// errors when the code is validated or rendered.
+type CodeBlockBuilder struct {
+	statements []Statement
+	blocks     []*controlFlowBlock
+}
+
+// controlFlowBlock is a block that has been opened and not yet ended.
+type controlFlowBlock struct {
+	opening     string // opening is the format of the statement that opened the block, e.g. "if ok {"
+	clauses     bool   // clauses is true for switch and select blocks, which contain case clauses
+	clauseOpen  bool   // clauseOpen is true once a case clause has been started
+	conditional bool   // conditional is true for if blocks, which can be followed by else if and else
+}
+
+// errorArgument is the argument of a statement that is in the wrong place, like an Else
+// without an if block. Writing a statement with an errorArgument returns its error.
+type errorArgument struct {
+	err error
+}
+
+var (
+	errElseWithoutIf     = errors.New("else without an open if block")
+	errCaseWithoutSwitch = errors.New("case without an open switch or select block")
+	errEndWithoutBlock   = errors.New("end without an open block")
+)
+
+// NewCodeBlockBuilder returns an empty CodeBlockBuilder.
+func NewCodeBlockBuilder() *CodeBlockBuilder {
+	return &CodeBlockBuilder{}
+}
+
+// GetStatements returns the statements built so far. Any blocks that are still open are
+// closed at the end of the statements, with an error reported when they are written, since
+// the statements after a missing End would otherwise end up in the wrong block.
+func (b *CodeBlockBuilder) GetStatements() []Statement {
+	statements := make([]Statement, len(b.statements), len(b.statements)+len(b.blocks))
+	copy(statements, b.statements)
+
+	for i := len(b.blocks) - 1; i >= 0; i-- {
+		end := b.blocks[i].end()
+		end.Arguments = []interface{}{errorArgument{fmt.Errorf("block %q is not ended with End", b.blocks[i].opening)}}
+		statements = append(statements, end)
+	}
+
+	return statements
+}
+
+// GetImports returns the imports used by the arguments of the statements built so far.
+func (b *CodeBlockBuilder) GetImports() []Import {
+	return statementImports(b.statements)
+}
+
+// Build returns Code made up of the statements built so far.
+func (b *CodeBlockBuilder) Build() *Code {
+	return NewCode(b.GetStatements()...)
+}
+
+// Statement appends a single statement at the current indentation.
+func (b *CodeBlockBuilder) Statement(format string, args ...interface{}) *CodeBlockBuilder {
+	b.statements = append(b.statements, newStatement(0, 0, format, args...))
+	return b
+}
+
+// If opens an if block with the given condition.
+func (b *CodeBlockBuilder) If(format string, args ...interface{}) *CodeBlockBuilder {
+	b.open("if "+format, args...)
+	b.blocks[len(b.blocks)-1].conditional = true
+	return b
+}
+
+// ElseIf ends the current if block and opens an else if block with the given condition.
+func (b *CodeBlockBuilder) ElseIf(format string, args ...interface{}) *CodeBlockBuilder {
+	if !b.inConditional() {
+		return b.misplaced("} else if "+format+" {", errElseWithoutIf)
+	}
+
+	b.statements = append(b.statements, newStatement(-1, 1, "} else if "+format+" {", args...))
+	return b
+}
+
+// Else ends the current if block and opens an else block.
+func (b *CodeBlockBuilder) Else() *CodeBlockBuilder {
+	if !b.inConditional() {
+		return b.misplaced("} else {", errElseWithoutIf)
+	}
+
+	b.statements = append(b.statements, newStatement(-1, 1, "} else {"))
+	// an else block ends the if statement, so it can't be followed by another else
+	b.blocks[len(b.blocks)-1].conditional = false
+	return b
+}
+
+// For opens a for loop with the given clause, e.g. "i := 0; i < $L; i++". An empty format
+// opens an infinite loop.
+func (b *CodeBlockBuilder) For(format string, args ...interface{}) *CodeBlockBuilder {
+	if format == "" {
+		return b.open("for")
+	}
+	return b.open("for "+format, args...)
+}
+
+// Range opens a for loop ranging over the expression given by the format string, assigning
+// to vars (e.g. "k, v"). If vars is empty the loop only ranges over the expression.
+func (b *CodeBlockBuilder) Range(vars string, format string, args ...interface{}) *CodeBlockBuilder {
+	if vars == "" {
+		return b.open("for range "+format, args...)
+	}
+	return b.open("for "+vars+" := range "+format, args...)
+}
+
+// Switch opens a switch statement on the given expression, which may be empty. Add clauses
+// to it with Case and Default.
+func (b *CodeBlockBuilder) Switch(format string, args ...interface{}) *CodeBlockBuilder {
+	if format == "" {
+		return b.openClauses("switch")
+	}
+	return b.openClauses("switch "+format, args...)
+}
+
+// Select opens a select statement. Add communication clauses to it with Case and Default.
+func (b *CodeBlockBuilder) Select() *CodeBlockBuilder {
+	return b.openClauses("select")
+}
+
+// Case starts a case clause of the current switch or select statement, ending the previous
+// clause. The format is the clause's expressions, e.g. "1, 2" or "v := <-ch".
+func (b *CodeBlockBuilder) Case(format string, args ...interface{}) *CodeBlockBuilder {
+	return b.clause("case "+format+":", args...)
+}
+
+// Default starts the default clause of the current switch or select statement, ending the
+// previous clause.
+func (b *CodeBlockBuilder) Default() *CodeBlockBuilder {
+	return b.clause("default:")
+}
+
+// Defer appends a defer statement for the given call.
+func (b *CodeBlockBuilder) Defer(format string, args ...interface{}) *CodeBlockBuilder {
+	return b.Statement("defer "+format, args...)
+}
+
+// Go appends a go statement for the given call.
+func (b *CodeBlockBuilder) Go(format string, args ...interface{}) *CodeBlockBuilder {
+	return b.Statement("go "+format, args...)
+}
+
+// End closes the innermost open block.
+func (b *CodeBlockBuilder) End() *CodeBlockBuilder {
+	if len(b.blocks) == 0 {
+		return b.misplaced("}", errEndWithoutBlock)
+	}
+
+	last := len(b.blocks) - 1
+	b.statements = append(b.statements, b.blocks[last].end())
+	b.blocks = b.blocks[:last]
+
+	return b
+}
+
+func (b *CodeBlockBuilder) open(format string, args ...interface{}) *CodeBlockBuilder {
+	b.statements = append(b.statements, newStatement(0, 1, format+" {", args...))
+	b.blocks = append(b.blocks, &controlFlowBlock{opening: format + " {"})
+	return b
+}
+
+func (b *CodeBlockBuilder) openClauses(format string, args ...interface{}) *CodeBlockBuilder {
+	b.statements = append(b.statements, newStatement(0, 0, format+" {", args...))
+	b.blocks = append(b.blocks, &controlFlowBlock{opening: format + " {", clauses: true})
+	return b
+}
+
+func (b *CodeBlockBuilder) clause(format string, args ...interface{}) *CodeBlockBuilder {
+	if len(b.blocks) == 0 || !b.blocks[len(b.blocks)-1].clauses {
+		return b.misplaced(format, errCaseWithoutSwitch)
+	}
+
+	before := 0
+	block := b.blocks[len(b.blocks)-1]
+	if block.clauseOpen {
+		before = -1
+	}
+	block.clauseOpen = true
+
+	b.statements = append(b.statements, newStatement(before, 1, format, args...))
+	return b
+}
+
+// inConditional reports whether the innermost open block is an if or else if block.
+func (b *CodeBlockBuilder) inConditional() bool {
+	return len(b.blocks) != 0 && b.blocks[len(b.blocks)-1].conditional
+}
+
+// misplaced appends a statement that returns the error when it is written. The format is kept
+// so that the error names the statement.
+func (b *CodeBlockBuilder) misplaced(format string, err error) *CodeBlockBuilder {
+	b.statements = append(b.statements, Statement{
+		Format:    format,
+		Arguments: []interface{}{errorArgument{err}},
+	})
+	return b
+}
+
+// end returns the statement closing the block.
+func (c *controlFlowBlock) end() Statement {
+	if c.clauses && !c.clauseOpen {
+		return newStatement(0, 0, "}")
+	}
+	return newStatement(-1, 0, "}")
+}
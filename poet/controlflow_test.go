@@ -0,0 +1,207 @@
+package poet
+
+import (
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+type ControlFlowSuite struct{}
+
+var _ = Suite(&ControlFlowSuite{})
+
+func writeStatements(statements []Statement) string {
+	w := newCodeWriter()
+	for _, s := range statements {
+		w.WriteStatement(s)
+	}
+	return w.String()
+}
+
+func (s *ControlFlowSuite) TestIfElse(c *C) {
+	expected := "" +
+		"if a > 1 {\n" +
+		"\tfmt.Println(a)\n" +
+		"} else if a < 0 {\n" +
+		"\treturn\n" +
+		"} else {\n" +
+		"\ta++\n" +
+		"}\n"
+
+	b := NewCodeBlockBuilder().
+		If("a > $L", 1).
+		Statement("$T(a)", TypeReferenceFromInstance(fmt.Println)).
+		ElseIf("a < $L", 0).
+		Statement("return").
+		Else().
+		Statement("a++").
+		End()
+
+	c.Assert(writeStatements(b.GetStatements()), Equals, expected)
+}
+
+func (s *ControlFlowSuite) TestLoops(c *C) {
+	expected := "" +
+		"for i := 0; i < 3; i++ {\n" +
+		"\tfor k, v := range m {\n" +
+		"\t\tfor range ch {\n" +
+		"\t\t\tfor {\n" +
+		"\t\t\t\tbreak\n" +
+		"\t\t\t}\n" +
+		"\t\t}\n" +
+		"\t}\n" +
+		"}\n"
+
+	b := NewCodeBlockBuilder().
+		For("i := 0; i < $L; i++", 3).
+		Range("k, v", "$L", "m").
+		Range("", "ch").
+		For("").
+		Statement("break").
+		End().
+		End().
+		End().
+		End()
+
+	c.Assert(writeStatements(b.GetStatements()), Equals, expected)
+}
+
+func (s *ControlFlowSuite) TestSwitch(c *C) {
+	expected := "" +
+		"switch v {\n" +
+		"case 1, 2:\n" +
+		"\ta()\n" +
+		"case 3:\n" +
+		"default:\n" +
+		"\tswitch {\n" +
+		"\tcase v > 10:\n" +
+		"\t\tb()\n" +
+		"\t}\n" +
+		"}\n"
+
+	b := NewCodeBlockBuilder().
+		Switch("v").
+		Case("$L, $L", 1, 2).
+		Statement("a()").
+		Case("3").
+		Default().
+		Switch("").
+		Case("v > 10").
+		Statement("b()").
+		End().
+		End()
+
+	c.Assert(writeStatements(b.GetStatements()), Equals, expected)
+}
+
+func (s *ControlFlowSuite) TestSelect(c *C) {
+	expected := "" +
+		"select {\n" +
+		"case v := <-ch:\n" +
+		"\tgo handle(v)\n" +
+		"case <-done:\n" +
+		"\treturn\n" +
+		"}\n"
+
+	b := NewCodeBlockBuilder().
+		Select().
+		Case("v := <-ch").
+		Go("handle(v)").
+		Case("<-done").
+		Statement("return").
+		End()
+
+	c.Assert(writeStatements(b.GetStatements()), Equals, expected)
+}
+
+func (s *ControlFlowSuite) TestEmptySwitch(c *C) {
+	expected := "" +
+		"switch {\n" +
+		"}\n"
+
+	b := NewCodeBlockBuilder().Switch("").End()
+
+	c.Assert(writeStatements(b.GetStatements()), Equals, expected)
+}
+
+func (s *ControlFlowSuite) TestDefer(c *C) {
+	expected := "" +
+		"defer f.Close()\n"
+
+	b := NewCodeBlockBuilder().Defer("$L.Close()", "f")
+
+	c.Assert(writeStatements(b.GetStatements()), Equals, expected)
+}
+
+func (s *ControlFlowSuite) TestOpenBlocksAreReported(c *C) {
+	b := NewCodeBlockBuilder().For("").Switch("v").Case("1").If("ok")
+
+	c.Assert(b.GetStatements(), HasLen, 7)
+	c.Assert(b.Build().Validate(), ErrorMatches, ""+
+		`code: statement 4 \("}"\): block "if ok {" is not ended with End\n`+
+		`code: statement 5 \("}"\): block "switch v {" is not ended with End\n`+
+		`code: statement 6 \("}"\): block "for {" is not ended with End`)
+	c.Assert(b.End().End().End().Build().Validate(), IsNil)
+}
+
+func (s *ControlFlowSuite) TestUnbalancedEnd(c *C) {
+	b := NewCodeBlockBuilder().If("ok").End().End()
+
+	c.Assert(b.Build().Validate(), ErrorMatches, `code: statement 2 \("}"\): end without an open block`)
+}
+
+func (s *ControlFlowSuite) TestCaseOutsideSwitch(c *C) {
+	b := NewCodeBlockBuilder().If("ok").Case("$L", 1).End()
+
+	c.Assert(b.Build().Validate(), ErrorMatches, `code: statement 1 \("case \$L:"\): case without an open switch or select block`)
+	c.Assert(NewCodeBlockBuilder().Default().Build().Validate(), ErrorMatches, `.*case without an open switch or select block`)
+}
+
+func (s *ControlFlowSuite) TestElseWithoutIf(c *C) {
+	for _, b := range []*CodeBlockBuilder{
+		NewCodeBlockBuilder().Else(),
+		NewCodeBlockBuilder().ElseIf("ok"),
+		NewCodeBlockBuilder().For("").Else().End(),
+		NewCodeBlockBuilder().If("ok").End().Else(),
+		NewCodeBlockBuilder().If("ok").Else().Else().End(),
+		NewCodeBlockBuilder().If("ok").Else().ElseIf("ok").End(),
+	} {
+		c.Check(b.Build().Validate(), ErrorMatches, `.*else without an open if block`)
+	}
+
+	c.Check(NewCodeBlockBuilder().If("a").ElseIf("b").ElseIf("c").Else().End().Build().Validate(), IsNil)
+}
+
+func (s *ControlFlowSuite) TestMisplacedStatementInFunction(c *C) {
+	fnc := NewFuncSpec("foo").AddCode(NewCodeBlockBuilder().Else().Build())
+
+	c.Assert(fnc.Validate(), ErrorMatches, `func foo: .*else without an open if block`)
+	c.Assert(func() { _ = fnc.String() }, PanicMatches, `.*else without an open if block`)
+}
+
+func (s *ControlFlowSuite) TestFunctionWithControlFlow(c *C) {
+	expected := "" +
+		"func foo(values []int) {\n" +
+		"\tfor _, v := range values {\n" +
+		"\t\tif v > 0 {\n" +
+		"\t\t\tfmt.Println(v)\n" +
+		"\t\t}\n" +
+		"\t}\n" +
+		"}\n"
+
+	b := NewCodeBlockBuilder().
+		Range("_, v", "values").
+		If("v > 0").
+		Statement("$T(v)", TypeReferenceFromInstance(fmt.Println)).
+		End().
+		End()
+	fnc := NewFuncSpec("foo").
+		Parameter("values", TypeReferenceFromInstance([]int{})).
+		AddStatements(b.GetStatements()...)
+
+	c.Assert(fnc.String(), Equals, expected)
+	c.Assert(fnc.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "fmt", Qualified: true},
+		(*ImportSpec)(nil),
+	})
+}
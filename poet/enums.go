@@ -0,0 +1,332 @@
+package poet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var _ CodeBlock = (*EnumSpec)(nil)
+var _ TypeReference = (*EnumSpec)(nil)
+
+// EnumMethods is a set of methods an EnumSpec generates for its type.
+type EnumMethods int
+
+const (
+	// EnumString generates a String method returning the text of a value
+	EnumString EnumMethods = 1 << iota
+	// EnumParse generates a Parse<Name> function returning the value for a text
+	EnumParse
+	// EnumValues generates a Values method returning every value in order
+	EnumValues
+	// EnumIsValid generates an IsValid method reporting whether a value is one of the enum's values
+	EnumIsValid
+	// EnumText generates MarshalText and UnmarshalText methods, along with String and Parse<Name>
+	EnumText
+
+	// EnumAllMethods generates every method
+	EnumAllMethods = EnumString | EnumParse | EnumValues | EnumIsValid | EnumText
+)
+
+// skippedEnumValue is the name of a value that is left out of an iota enum.
+const skippedEnumValue = "_"
+
+var (
+	fmtSprintf = TypeReferenceFromInstance(fmt.Sprintf)
+	fmtErrorf  = TypeReferenceFromInstance(fmt.Errorf)
+)
+
+// EnumSpec declares a named integer or string type, a constant block with its values and,
+// optionally, methods to convert the values to and from text. The values of an integer enum
+// are numbered with iota, while the values of a string enum are their text.
+type EnumSpec struct {
+	Name           string
+	Import         *ImportSpec // Import is the package the enum is declared in, or nil for the file's own package
+	Comment        string
+	UnderlyingType TypeReference // UnderlyingType is an integer type, or String
+	Offset         int           // Offset is added to iota, so the first value of an integer enum is Offset
+	Values         []*EnumValue
+	Methods        EnumMethods
+}
+
+// EnumValue is a constant of an EnumSpec.
+type EnumValue struct {
+	Name string // Name of the constant, or _ for a value skipped by an integer enum
+	Text string // Text is the value's text for String and Parse<Name>, and the value of a string enum
+}
+
+// NewEnumSpec returns an enum with the given name and underlying type, like Int or String.
+func NewEnumSpec(name string, underlying TypeReference) *EnumSpec {
+	return &EnumSpec{
+		Name:           name,
+		UnderlyingType: underlying,
+	}
+}
+
+// EnumComment adds a comment to the enum's type.
+func (e *EnumSpec) EnumComment(comment string) *EnumSpec {
+	e.Comment = comment
+	return e
+}
+
+// InPackage declares the enum in the package with the given import path and name, as for
+// StructSpec.InPackage.
+func (e *EnumSpec) InPackage(importPath, name string) *EnumSpec {
+	e.Import = declaredIn(importPath, name)
+	return e
+}
+
+// Value adds a constant to the enum. The text is the constant's name if it is empty.
+func (e *EnumSpec) Value(name, text string) *EnumSpec {
+	if text == "" {
+		text = name
+	}
+	e.Values = append(e.Values, &EnumValue{
+		Name: name,
+		Text: text,
+	})
+	return e
+}
+
+// Skip leaves out the next value of an integer enum, writing _ in its place.
+func (e *EnumSpec) Skip() *EnumSpec {
+	e.Values = append(e.Values, &EnumValue{Name: skippedEnumValue})
+	return e
+}
+
+// StartAt numbers the values of an integer enum from the given offset instead of 0.
+func (e *EnumSpec) StartAt(offset int) *EnumSpec {
+	e.Offset = offset
+	return e
+}
+
+// WithMethods adds methods for the enum to generate.
+func (e *EnumSpec) WithMethods(methods EnumMethods) *EnumSpec {
+	e.Methods |= methods
+	return e
+}
+
+// GetName returns the name of the enum's type, qualified if it is declared in a package.
+func (e *EnumSpec) GetName() string {
+	return e.getNameIn(nil)
+}
+
+func (e *EnumSpec) getNameIn(names *importNames) string {
+	return names.qualifier(e.Import) + e.Name
+}
+
+func (e *EnumSpec) referenceImports() []Import {
+	return []Import{e.Import}
+}
+
+// GetImports returns the imports used by the enum's declarations.
+func (e *EnumSpec) GetImports() []Import {
+	imports := []Import{}
+	for _, blk := range e.declarations() {
+		imports = append(imports, blk.GetImports()...)
+	}
+	return imports
+}
+
+func (e *EnumSpec) String() string {
+	return mustWriteCodeBlock(e)
+}
+
+// Validate returns an ErrorList of the enum's statements that cannot be written, or nil.
+func (e *EnumSpec) Validate() error {
+	_, err := writeCodeBlock(e)
+	return err
+}
+
+// GetStatements returns the enum's type, constants and methods as statements.
+func (e *EnumSpec) GetStatements() []Statement {
+	var statements []Statement
+
+	for i, blk := range e.declarations() {
+		if i > 0 {
+			statements = append(statements, Statement{})
+		}
+		statements = append(statements, blk.GetStatements()...)
+	}
+	// the enum writes the bodies of its methods too, so none of its statements are the user's
+	return generated(statements...)
+}
+
+// enumDeclaration is a declaration written by an EnumSpec.
+type enumDeclaration interface {
+	CodeBlock
+	statementBlock
+}
+
+// declarations returns the type, the constant block and the generated methods of the enum.
+func (e *EnumSpec) declarations() []enumDeclaration {
+	self := NewTypeReference("", e.Name)
+	typeSpec := NewTypeSpec(e.Name, e.UnderlyingType).TypeComment(e.Comment)
+	blocks := []enumDeclaration{typeSpec, e.constants(self)}
+
+	methods := e.Methods
+	if methods&EnumText != 0 {
+		methods |= EnumString | EnumParse
+	}
+	values := e.definedValues()
+	receiver := e.receiverName()
+
+	if methods&EnumString != 0 {
+		m := typeSpec.Method("String", receiver, false)
+		m.FunctionComment(fmt.Sprintf("String returns the text of the %s.", e.Name)).
+			ResultParameter("", String)
+		if e.isString() {
+			m.Statement("return string($L)", receiver)
+		} else {
+			b := NewCodeBlockBuilder().Switch(receiver)
+			for _, v := range values {
+				b.Case(v.Name).Statement("return $S", v.Text)
+			}
+			b.End().Statement("return $T($S, $L)", fmtSprintf, e.Name+"(%d)", receiver)
+			m.AddCode(b.Build())
+		}
+		blocks = append(blocks, m)
+	}
+
+	if methods&EnumParse != 0 {
+		name := "Parse" + e.Name
+		f := NewFuncSpec(name).
+			FunctionComment(fmt.Sprintf("%s returns the %s with the given text.", name, e.Name)).
+			Parameter("text", String).
+			ResultParameter("", self).
+			ResultParameter("", Error)
+		b := NewCodeBlockBuilder().Switch("text")
+		for _, v := range values {
+			b.Case("$S", v.Text).Statement("return $L, nil", v.Name)
+		}
+		b.End().Statement("return $L, $T($S, text)", e.zeroValue(), fmtErrorf, "invalid "+e.Name+" %q")
+		f.AddCode(b.Build())
+		blocks = append(blocks, f)
+	}
+
+	if methods&EnumValues != 0 {
+		m := typeSpec.Method("Values", receiver, false)
+		m.FunctionComment(fmt.Sprintf("Values returns every %s in order.", e.Name)).
+			ResultParameter("", SliceOf(self)).
+			Statement("return []$T{$L}", self, strings.Join(enumValueNames(values), ", "))
+		blocks = append(blocks, m)
+	}
+
+	if methods&EnumIsValid != 0 {
+		m := typeSpec.Method("IsValid", receiver, false)
+		m.FunctionComment(fmt.Sprintf("IsValid reports whether the %s is one of its declared values.", e.Name)).
+			ResultParameter("", Bool)
+		if len(values) != 0 {
+			m.AddCode(NewCodeBlockBuilder().
+				Switch(receiver).
+				Case(strings.Join(enumValueNames(values), ", ")).
+				Statement("return true").
+				End().
+				Build())
+		}
+		m.Statement("return false")
+		blocks = append(blocks, m)
+	}
+
+	if methods&EnumText != 0 {
+		marshal := typeSpec.Method("MarshalText", receiver, false)
+		marshal.FunctionComment("MarshalText implements encoding.TextMarshaler.").
+			ResultParameter("", SliceOf(Byte)).
+			ResultParameter("", Error).
+			Statement("return []byte($L.String()), nil", receiver)
+
+		unmarshal := typeSpec.Method("UnmarshalText", receiver, true)
+		unmarshal.FunctionComment("UnmarshalText implements encoding.TextUnmarshaler.").
+			Parameter("text", SliceOf(Byte)).
+			ResultParameter("", Error).
+			Statement("parsed, err := Parse$L(string(text))", e.Name).
+			AddCode(NewCodeBlockBuilder().If("err != nil").Statement("return err").End().Build()).
+			Statement("*$L = parsed", receiver).
+			Statement("return nil")
+		blocks = append(blocks, marshal, unmarshal)
+	}
+
+	return blocks
+}
+
+// constants returns the constant block declaring the enum's values.
+func (e *EnumSpec) constants(self TypeReference) *VariableGrouping {
+	g := &VariableGrouping{}
+
+	if e.isString() {
+		for _, v := range e.definedValues() {
+			g.Constant(v.Name, self, "$S", v.Text)
+		}
+		return g
+	}
+
+	for i, v := range e.Values {
+		if i > 0 {
+			g.Constant(v.Name, nil, "")
+			continue
+		}
+		switch {
+		case e.Offset > 0:
+			g.Constant(v.Name, self, "iota + $L", e.Offset)
+		case e.Offset < 0:
+			g.Constant(v.Name, self, "iota - $L", -e.Offset)
+		default:
+			g.Constant(v.Name, self, "iota")
+		}
+	}
+	return g
+}
+
+// definedValues returns the values that are not skipped.
+func (e *EnumSpec) definedValues() []*EnumValue {
+	values := []*EnumValue{}
+	for _, v := range e.Values {
+		if v.Name != skippedEnumValue {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func (e *EnumSpec) isString() bool {
+	return isStringType(e.UnderlyingType)
+}
+
+func (e *EnumSpec) zeroValue() string {
+	if e.isString() {
+		return `""`
+	}
+	return "0"
+}
+
+// receiverName returns the first letter of the enum's name in lower case, e.g. s for Status.
+// If that is the name of one of the enum's values, which the receiver would hide, or of a
+// variable in the generated methods, the letter is numbered, e.g. s2.
+func (e *EnumSpec) receiverName() string {
+	r, _ := utf8.DecodeRuneInString(e.Name)
+	letter := "e"
+	if unicode.IsLetter(r) {
+		letter = string(unicode.ToLower(r))
+	}
+
+	taken := map[string]bool{"text": true, "parsed": true, "err": true}
+	for _, v := range e.Values {
+		taken[v.Name] = true
+	}
+
+	name := letter
+	for i := 2; taken[name]; i++ {
+		name = letter + strconv.Itoa(i)
+	}
+	return name
+}
+
+func enumValueNames(values []*EnumValue) []string {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = v.Name
+	}
+	return names
+}
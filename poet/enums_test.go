@@ -0,0 +1,272 @@
+package poet
+
+import (
+	"go/ast"
+	goimporter "go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	. "gopkg.in/check.v1"
+)
+
+type EnumsSuite struct{}
+
+var _ = Suite(&EnumsSuite{})
+
+func (s *EnumsSuite) TestIntEnum(c *C) {
+	expected := "" +
+		"// Status is the status of a job\n" +
+		"type Status int\n" +
+		"\n" +
+		"const (\n" +
+		"\tStatusUnknown Status = iota\n" +
+		"\tStatusRunning\n" +
+		"\t_\n" +
+		"\tStatusDone\n" +
+		")\n"
+
+	enum := NewEnumSpec("Status", Int).
+		EnumComment("Status is the status of a job").
+		Value("StatusUnknown", "").
+		Value("StatusRunning", "").
+		Skip().
+		Value("StatusDone", "")
+
+	c.Assert(enum.String(), Equals, expected)
+}
+
+func (s *EnumsSuite) TestIntEnumOffset(c *C) {
+	c.Check(NewEnumSpec("Level", Uint8).StartAt(1).Value("LevelLow", "").Value("LevelHigh", "").String(), Equals, ""+
+		"type Level uint8\n"+
+		"\n"+
+		"const (\n"+
+		"\tLevelLow Level = iota + 1\n"+
+		"\tLevelHigh\n"+
+		")\n")
+	c.Check(NewEnumSpec("Sign", Int).StartAt(-1).Value("Negative", "").Value("Zero", "").Value("Positive", "").String(), Equals, ""+
+		"type Sign int\n"+
+		"\n"+
+		"const (\n"+
+		"\tNegative Sign = iota - 1\n"+
+		"\tZero\n"+
+		"\tPositive\n"+
+		")\n")
+}
+
+func (s *EnumsSuite) TestStringEnum(c *C) {
+	expected := "" +
+		"type Color string\n" +
+		"\n" +
+		"const (\n" +
+		"\tColorRed Color = \"red\"\n" +
+		"\tColorGreen Color = \"green\"\n" +
+		")\n" +
+		"\n" +
+		"// String returns the text of the Color.\n" +
+		"func (c Color) String() string {\n" +
+		"\treturn string(c)\n" +
+		"}\n" +
+		"\n" +
+		"// ParseColor returns the Color with the given text.\n" +
+		"func ParseColor(text string) (Color, error) {\n" +
+		"\tswitch text {\n" +
+		"\tcase \"red\":\n" +
+		"\t\treturn ColorRed, nil\n" +
+		"\tcase \"green\":\n" +
+		"\t\treturn ColorGreen, nil\n" +
+		"\t}\n" +
+		"\treturn \"\", fmt.Errorf(\"invalid Color %q\", text)\n" +
+		"}\n"
+
+	enum := NewEnumSpec("Color", String).
+		Value("ColorRed", "red").
+		Skip().
+		Value("ColorGreen", "green").
+		WithMethods(EnumString | EnumParse)
+
+	c.Assert(enum.String(), Equals, expected)
+}
+
+func (s *EnumsSuite) TestEnumFile(c *C) {
+	expected := "" +
+		"package jobs\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"fmt\"\n" +
+		")\n" +
+		"\n" +
+		"type Status int\n" +
+		"\n" +
+		"const (\n" +
+		"\tStatusUnknown Status = iota\n" +
+		"\tStatusRunning\n" +
+		"\t_\n" +
+		"\tStatusDone\n" +
+		")\n" +
+		"\n" +
+		"// String returns the text of the Status.\n" +
+		"func (s Status) String() string {\n" +
+		"\tswitch s {\n" +
+		"\tcase StatusUnknown:\n" +
+		"\t\treturn \"unknown\"\n" +
+		"\tcase StatusRunning:\n" +
+		"\t\treturn \"running\"\n" +
+		"\tcase StatusDone:\n" +
+		"\t\treturn \"done\"\n" +
+		"\t}\n" +
+		"\treturn fmt.Sprintf(\"Status(%d)\", s)\n" +
+		"}\n" +
+		"\n" +
+		"// ParseStatus returns the Status with the given text.\n" +
+		"func ParseStatus(text string) (Status, error) {\n" +
+		"\tswitch text {\n" +
+		"\tcase \"unknown\":\n" +
+		"\t\treturn StatusUnknown, nil\n" +
+		"\tcase \"running\":\n" +
+		"\t\treturn StatusRunning, nil\n" +
+		"\tcase \"done\":\n" +
+		"\t\treturn StatusDone, nil\n" +
+		"\t}\n" +
+		"\treturn 0, fmt.Errorf(\"invalid Status %q\", text)\n" +
+		"}\n" +
+		"\n" +
+		"// Values returns every Status in order.\n" +
+		"func (s Status) Values() []Status {\n" +
+		"\treturn []Status{StatusUnknown, StatusRunning, StatusDone}\n" +
+		"}\n" +
+		"\n" +
+		"// IsValid reports whether the Status is one of its declared values.\n" +
+		"func (s Status) IsValid() bool {\n" +
+		"\tswitch s {\n" +
+		"\tcase StatusUnknown, StatusRunning, StatusDone:\n" +
+		"\t\treturn true\n" +
+		"\t}\n" +
+		"\treturn false\n" +
+		"}\n" +
+		"\n" +
+		"// MarshalText implements encoding.TextMarshaler.\n" +
+		"func (s Status) MarshalText() ([]byte, error) {\n" +
+		"\treturn []byte(s.String()), nil\n" +
+		"}\n" +
+		"\n" +
+		"// UnmarshalText implements encoding.TextUnmarshaler.\n" +
+		"func (s *Status) UnmarshalText(text []byte) error {\n" +
+		"\tparsed, err := ParseStatus(string(text))\n" +
+		"\tif err != nil {\n" +
+		"\t\treturn err\n" +
+		"\t}\n" +
+		"\t*s = parsed\n" +
+		"\treturn nil\n" +
+		"}\n" +
+		"\n" +
+		"var current Status = StatusRunning\n"
+
+	enum := NewEnumSpec("Status", Int).
+		Value("StatusUnknown", "unknown").
+		Value("StatusRunning", "running").
+		Skip().
+		Value("StatusDone", "done").
+		WithMethods(EnumAllMethods)
+
+	fspec := NewFileSpec("jobs")
+	fspec.CodeBlock(enum)
+	fspec.GlobalVariable("current", enum, "$L", "StatusRunning")
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (s *EnumsSuite) TestEnumTextMethodsIncludeStringAndParse(c *C) {
+	enum := NewEnumSpec("Status", Int).Value("StatusOK", "").WithMethods(EnumText)
+	actual := enum.String()
+
+	c.Assert(actual, Matches, "(?s).*func \\(s Status\\) String\\(\\) string.*")
+	c.Assert(actual, Matches, "(?s).*func ParseStatus\\(text string\\).*")
+
+	packages := []string{}
+	for _, i := range enum.GetImports() {
+		if i.GetPackage() != "" {
+			packages = append(packages, i.GetPackage())
+		}
+	}
+	c.Assert(packages, DeepEquals, []string{"fmt", "fmt"})
+}
+
+func (s *EnumsSuite) TestEnumMethodsCompile(c *C) {
+	// the receiver of Visibility's methods is v, which must not collide with the generated code
+	for _, enum := range []*EnumSpec{
+		NewEnumSpec("Visibility", Int).Value("Public", "public").Value("Private", "private"),
+		NewEnumSpec("Prefix", String).Value("PrefixNone", "").Value("PrefixWide", "wide"),
+		NewEnumSpec("Errors", Uint8).Value("ErrorsNone", "").Skip().Value("ErrorsAll", ""),
+		NewEnumSpec("_hidden", Int).Value("hiddenA", ""),
+	} {
+		fspec := NewFileSpec("enums")
+		fspec.CodeBlock(enum.WithMethods(EnumAllMethods))
+		code, err := fspec.Render()
+		c.Assert(err, IsNil)
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "enums.go", code, 0)
+		c.Assert(err, IsNil)
+		conf := types.Config{Importer: goimporter.ForCompiler(fset, "source", nil)}
+		_, err = conf.Check("example.com/enums", fset, []*ast.File{file}, nil)
+		c.Assert(err, IsNil, Commentf("%s", code))
+	}
+}
+
+func (s *EnumsSuite) TestEnumReceiverDoesNotHideValues(c *C) {
+	expected := "" +
+		"type Color int\n" +
+		"\n" +
+		"const (\n" +
+		"\tc Color = iota\n" +
+		"\tc2\n" +
+		")\n" +
+		"\n" +
+		"// String returns the text of the Color.\n" +
+		"func (c3 Color) String() string {\n" +
+		"\tswitch c3 {\n" +
+		"\tcase c:\n" +
+		"\t\treturn \"c\"\n" +
+		"\tcase c2:\n" +
+		"\t\treturn \"c2\"\n" +
+		"\t}\n" +
+		"\treturn fmt.Sprintf(\"Color(%d)\", c3)\n" +
+		"}\n" +
+		"\n" +
+		"// IsValid reports whether the Color is one of its declared values.\n" +
+		"func (c3 Color) IsValid() bool {\n" +
+		"\tswitch c3 {\n" +
+		"\tcase c, c2:\n" +
+		"\t\treturn true\n" +
+		"\t}\n" +
+		"\treturn false\n" +
+		"}\n"
+
+	enum := NewEnumSpec("Color", Int).Value("c", "").Value("c2", "").WithMethods(EnumString | EnumIsValid)
+
+	c.Assert(enum.String(), Equals, expected)
+	c.Assert(NewEnumSpec("Text", String).Value("t", "").receiverName(), Equals, "t2")
+	c.Assert(NewEnumSpec("Error", Int).Value("e", "").receiverName(), Equals, "e2")
+}
+
+func (s *EnumsSuite) TestNamedStringEnum(c *C) {
+	type label string
+
+	name := NewTypeSpec("Name", String)
+	for _, underlying := range []TypeReference{
+		name,
+		TypeReferenceFromInstance(label("")),
+		TypeReferenceFromReflectType(reflect.TypeOf(label(""))),
+		NewEnumSpec("Other", String),
+	} {
+		enum := NewEnumSpec("Color", underlying).Value("ColorRed", "red")
+		c.Check(enum.String(), Matches, `(?s).*ColorRed Color = "red".*`, Commentf("%s", underlying.GetName()))
+	}
+
+	c.Check(NewEnumSpec("Color", NewTypeSpec("Level", Int)).Value("ColorRed", "red").String(), Matches, `(?s).*ColorRed Color = iota.*`)
+	c.Check(NewEnumSpec("Color", TypeReferenceFromInstance(new(label))).isString(), Equals, false)
+}
@@ -2,15 +2,19 @@ package poet
 
 import (
 	"fmt"
+	"go/format"
 )
 
 // FileSpec represents a .go source file
 type FileSpec struct {
 	Comment                string
-	Package                string      // Package that the file belongs to
-	InitializationPackages []Import    // InitializationPackages include any imports that need to be included for their side effects
-	Init                   *FuncSpec   // Init is a single function to be outputted before all CodeBlocks
-	CodeBlocks             []CodeBlock // CodeBlocks are appended and when outputted separated by a newline
+	Package                string            // Package that the file belongs to
+	ImportPath             string            // ImportPath of the package, so the file doesn't import its own package
+	InitializationPackages []Import          // InitializationPackages include any imports that need to be included for their side effects
+	ImportLayout           ImportLayout      // ImportLayout controls the grouping and order of the file's imports
+	PackageNames           map[string]string // PackageNames maps import paths to package names that cannot be derived from the path
+	Init                   *FuncSpec         // Init is a single function to be outputted before all CodeBlocks
+	CodeBlocks             []CodeBlock       // CodeBlocks are appended and when outputted separated by a newline
 }
 
 // NewFileSpec constructs a new FileSpec with the given package name
@@ -22,26 +26,101 @@ func NewFileSpec(pkg string) *FileSpec {
 	}
 }
 
-// String produces the final go file string
+// String produces the final go file string. Packages imported by more than one CodeBlock
+// under the same name are given distinct aliases, which every $T in the file uses.
+// String panics if the file is invalid; use Validate or Render to check for errors instead.
 func (f *FileSpec) String() string {
+	w, err := f.write()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return w.String()
+}
+
+// Validate returns an ErrorList of the problems with the file and the statements of its
+// CodeBlocks, or nil if the file can be written.
+func (f *FileSpec) Validate() error {
+	_, err := f.write()
+	return err
+}
+
+// Render produces the final go file string formatted with go/format. If the file is invalid,
+// Render returns the error from Validate. If the generated code cannot be parsed, Render
+// returns a *FormatError pointing at the offending line and the CodeBlock that produced it.
+func (f *FileSpec) Render() (string, error) {
+	w, err := f.write()
+	if err != nil {
+		return "", err
+	}
+	code := w.String()
+
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		return "", newFormatError(w, code, err)
+	}
+
+	return string(formatted), nil
+}
+
+// write writes the file, returning an ErrorList of the problems found along the way.
+func (f *FileSpec) write() (*codeWriter, error) {
 	w := newCodeWriter()
+	imports := collectImports(f.InitializationPackages, f.CodeBlocks, f.ImportPath)
+	w.names = newImportNames(imports, f.PackageNames)
+	w.names.self = f.ImportPath
 
 	f.writeHeader(w)
-	f.writeImports(w)
+	f.writeImports(w, w.names.aliased(imports))
 	f.writeInitFunc(w)
 	f.writeCodeBlocks(w)
 
-	return w.String()
+	var errs ErrorList
+	if f.Init != nil && f.Init.Name != "init" {
+		errs = append(errs, &SpecError{
+			Spec:      describeCodeBlock(f.Init),
+			Statement: -1,
+			Err:       fmt.Errorf("the init function must be named 'init' (got '%s')", f.Init.Name),
+		})
+	}
+	errs = append(errs, w.errs...)
+	if len(errs) != 0 {
+		return w, errs
+	}
+
+	return w, nil
 }
 
 // InitializationPackage appends an initialization package for its side effects
 func (f *FileSpec) InitializationPackage(imp Import) *FileSpec {
 	if imp.GetPackage() != "" {
-		f.InitializationPackages = append(f.InitializationPackages, &ImportSpec{
-			Package: imp.GetPackage(),
-			Alias:   "_",
-		})
+		f.InitializationPackages = append(f.InitializationPackages, NewImportSpec(imp.GetPackage(), ImportBlank, ""))
+	}
+	return f
+}
+
+// InPackage sets the import path of the package the file belongs to. Types and functions from
+// that package, whether they come from specs or reflection, are referred to without a qualifier,
+// and the package is never imported, even with an alias or as an initialization package.
+func (f *FileSpec) InPackage(importPath string) *FileSpec {
+	f.ImportPath = importPath
+	return f
+}
+
+// LocalImportPrefix adds a package path prefix whose imports are grouped separately after
+// third-party imports, typically the path of the module being generated.
+func (f *FileSpec) LocalImportPrefix(prefix string) *FileSpec {
+	f.ImportLayout.LocalPrefixes = append(f.ImportLayout.LocalPrefixes, prefix)
+	return f
+}
+
+// PackageName records the name of the package at the import path, for packages whose name
+// cannot be derived from their path.
+func (f *FileSpec) PackageName(importPath, name string) *FileSpec {
+	if f.PackageNames == nil {
+		f.PackageNames = make(map[string]string)
 	}
+	f.PackageNames[importPath] = name
 	return f
 }
 
@@ -51,12 +130,9 @@ func (f *FileSpec) CodeBlock(blk CodeBlock) *FileSpec {
 	return f
 }
 
-// InitFunction assign an init function
+// InitFunction assign an init function. The function must be named init, which is checked
+// when the file is written.
 func (f *FileSpec) InitFunction(blk *FuncSpec) *FileSpec {
-	if blk.Name != "init" {
-		panic(fmt.Sprintf("the init function must be named 'init' (got '%s')", f.Init.Name))
-	}
-
 	f.Init = blk
 	return f
 }
@@ -106,31 +182,29 @@ func (f *FileSpec) FileComment(comment string) *FileSpec {
 }
 
 func (f *FileSpec) writeHeader(w *codeWriter) {
+	w.BeginSection("package clause")
 	if f.Comment != "" {
 		w.WriteCodeBlock(Comment(f.Comment))
 	}
 	w.WriteStatement(newStatement(0, 0, "package $L\n", f.Package))
 }
 
-func (f *FileSpec) writeImports(w *codeWriter) {
-	imports := collectImports(f.InitializationPackages, f.CodeBlocks)
-	if len(imports) == 0 {
+func (f *FileSpec) writeImports(w *codeWriter, imports []Import) {
+	statements := f.ImportLayout.GetStatements(imports)
+	if len(statements) == 0 {
 		return
 	}
 
-	w.WriteStatement(newStatement(0, 1, "import ("))
-	for _, i := range imports {
-		var prefix string
-		if i.GetAlias() != "" {
-			prefix = i.GetAlias() + " "
-		}
-		w.WriteStatement(newStatement(0, 0, "$L$S", prefix, i.GetPackage()))
+	w.BeginSection("imports")
+	for _, s := range statements {
+		w.WriteStatement(s)
 	}
-	w.WriteStatement(newStatement(-1, 0, ")\n"))
+	w.WriteStatement(Statement{})
 }
 
 func (f *FileSpec) writeInitFunc(w *codeWriter) {
 	if f.Init != nil {
+		w.BeginSection(describeCodeBlock(f.Init))
 		w.WriteCodeBlock(f.Init)
 		w.WriteStatement(Statement{})
 	}
@@ -138,40 +212,61 @@ func (f *FileSpec) writeInitFunc(w *codeWriter) {
 
 func (f *FileSpec) writeCodeBlocks(w *codeWriter) {
 	for _, blk := range f.CodeBlocks {
+		w.BeginSection(describeCodeBlock(blk))
 		w.WriteCodeBlock(blk)
 		w.WriteStatement(Statement{})
 	}
 }
 
-func collectImports(initPackages []Import, codeBlocks []CodeBlock) []Import {
-	// map[Package]map[Alias]Import
-	packages := make(map[string]map[string]Import)
-	for _, i := range initPackages {
-		pkg := i.GetPackage()
-		if _, exists := packages[pkg]; !exists {
-			packages[pkg] = make(map[string]Import)
+// collectImports returns the distinct package and alias pairs imported by the file, in the
+// order they are first used. Imports are compared by mode rather than by how the mode was
+// given, so an ImportSpec with Mode ImportDot and one with the alias . are the same import,
+// while a package used in several modes is imported once for each. A blank import is dropped
+// when its package is also imported in another mode, since that import runs its init as well.
+func collectImports(initPackages []Import, codeBlocks []CodeBlock, self string) []Import {
+	type importKey struct {
+		pkg   string
+		alias string
+	}
+	seen := make(map[importKey]bool)
+	// imported is the set of packages imported in a mode other than blank
+	imported := make(map[string]bool)
+	var pkgSlice []Import
+
+	add := func(i Import) {
+		// the file's own package is never imported, since that would be an import cycle
+		if i.GetPackage() == self {
+			return
+		}
+		key := importKey{i.GetPackage(), i.GetAlias()}
+		if !seen[key] {
+			seen[key] = true
+			pkgSlice = append(pkgSlice, i)
+		}
+		if importMode(i) != ImportBlank {
+			imported[i.GetPackage()] = true
 		}
-		packages[i.GetPackage()][i.GetAlias()] = i
+	}
+
+	for _, i := range initPackages {
+		add(i)
 	}
 	// Collect the imports from each code block
 	for _, blk := range codeBlocks {
 		for _, i := range blk.GetImports() {
-			pkg := i.GetPackage()
 			// external packages only
-			if pkg != "" {
-				if _, exists := packages[pkg]; !exists {
-					packages[pkg] = make(map[string]Import)
-				}
-				packages[pkg][i.GetAlias()] = i
+			if i.GetPackage() != "" {
+				add(i)
 			}
 		}
 	}
 
-	var pkgSlice []Import
-	for _, aliasMap := range packages {
-		for _, i := range aliasMap {
-			pkgSlice = append(pkgSlice, i)
+	imports := pkgSlice[:0]
+	for _, i := range pkgSlice {
+		if importMode(i) == ImportBlank && imported[i.GetPackage()] {
+			continue
 		}
+		imports = append(imports, i)
 	}
-	return pkgSlice
+	return imports
 }
@@ -2,10 +2,15 @@ package poet
 
 import (
 	"bytes"
+	crand "crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	mrand "math/rand"
 	"strings"
 	"testing"
 
+	"golang.org/x/net/context"
 	. "gopkg.in/check.v1"
 )
 
@@ -160,12 +165,40 @@ func (f *FilesSuite) TestFileMultipleImports(c *C) {
 		Parameter("b", TypeReferenceFromInstanceWithAlias(&bytes.Buffer{}, "blah")))
 
 	actual := fspec.String()
-	c.Assert(strings.Count(actual, "\t_ \"bytes\"\n"), Equals, 1)
+	c.Assert(strings.Count(actual, "\t_ \"bytes\"\n"), Equals, 0)
 	c.Assert(strings.Count(actual, "\t_ \"context\"\n"), Equals, 1)
 	c.Assert(strings.Count(actual, "\tblah \"bytes\"\n"), Equals, 1)
 	c.Assert(strings.Count(actual, "\t\"bytes\"\n"), Equals, 1)
 }
 
+func (f *FilesSuite) TestFileBlankImportOfImportedPackage(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"image/png\"\n" +
+		")\n" +
+		"\n" +
+		"var a png.Encoder\n" +
+		"\n"
+
+	fspec := NewFileSpec("foo")
+	fspec.InitializationPackage(NewImportSpec("image/png", ImportBlank, ""))
+	fspec.GlobalVariable("a", NewTypeReferenceWithImport(NewImportSpec("image/png", ImportNormal, ""), "Encoder"), "")
+
+	c.Assert(fspec.String(), Equals, expected)
+}
+
+func (f *FilesSuite) TestFileTypeFromBlankImport(c *C) {
+	encoder := NewTypeReferenceWithImport(NewImportSpec("image/png", ImportBlank, ""), "Encoder")
+	fspec := NewFileSpec("foo")
+	fspec.GlobalVariable("a", PointerTo(encoder), "")
+
+	c.Assert(fspec.Validate(), ErrorMatches, `var a: .*: \$T "\*Encoder" refers to the blank import of "image/png"`)
+	_, err := fspec.Render()
+	c.Assert(err, NotNil)
+}
+
 func (f *FilesSuite) TestFileGlobalVariable(c *C) {
 	expected := "" +
 		"package foo\n" +
@@ -260,10 +293,57 @@ func (f *FilesSuite) TestFileInitFunctionPanicsWithWrongName(c *C) {
 	fspec := NewFileSpec("foo")
 	initFunc := NewFuncSpec("bar")
 	fspec.InitFunction(initFunc)
+	_ = fspec.String()
 
 	c.Fail()
 }
 
+func (f *FilesSuite) TestFileValidateInitFunctionWithWrongName(c *C) {
+	fspec := NewFileSpec("foo").InitFunction(NewFuncSpec("bar"))
+
+	err := fspec.Validate()
+	c.Assert(err, ErrorMatches, "func bar: the init function must be named 'init' \\(got 'bar'\\)")
+
+	_, err = fspec.Render()
+	c.Assert(err, NotNil)
+}
+
+func (f *FilesSuite) TestFileValidateCollectsErrors(c *C) {
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(NewFuncSpec("a").
+		Statement("$T()", 1).
+		Statement("$L"))
+	fspec.GlobalVariable("b", Int, "$D", 1)
+	fspec.CodeBlock(NewFuncSpec("c").Statement("$L", 1))
+
+	err := fspec.Validate()
+	c.Assert(err, NotNil)
+
+	errs, ok := err.(ErrorList)
+	c.Assert(ok, Equals, true)
+	c.Assert(errs, HasLen, 3)
+	c.Check(errs[0], DeepEquals, &SpecError{
+		Spec:      "func a",
+		Statement: 0,
+		Format:    "$T()",
+		Err:       errors.New("$T must implement TypeReference, got type=int 1"),
+	})
+	c.Check(errs[1].(*SpecError).Spec, Equals, "func a")
+	c.Check(errs[1].(*SpecError).Statement, Equals, 1)
+	c.Check(errs[2].(*SpecError).Spec, Equals, "var b")
+	c.Check(errs[2].(*SpecError).Statement, Equals, -1)
+	c.Check(errs[2].Error(), Equals, "var b: \"$L$L $T = $D\": Unrecognized templating character in format string ('$L$L $T = $D')")
+
+	_, err = fspec.Render()
+	c.Assert(err, DeepEquals, errs)
+}
+
+func (f *FilesSuite) TestFileValidate(c *C) {
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(NewFuncSpec("a").Statement("$T()", TypeReferenceFromInstance(fmt.Println)))
+	c.Assert(fspec.Validate(), IsNil)
+}
+
 func (f *FilesSuite) TestFileComment(c *C) {
 	expected := "" +
 		"// This is a comment.\n" +
@@ -282,3 +362,357 @@ func (f *FilesSuite) TestFileDoesNotShowEmptyComment(c *C) {
 	actual := NewFileSpec("foo").String()
 	c.Assert(actual, Equals, expected)
 }
+
+func (f *FilesSuite) TestFileImportsAreSorted(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"bytes\"\n" +
+		"\t\"fmt\"\n" +
+		"\t\"io\"\n" +
+		"\n" +
+		"\t\"golang.org/x/net/context\"\n" +
+		"\n" +
+		"\t_ \"image/png\"\n" +
+		")\n" +
+		"\n" +
+		"func blah(a io.Reader, b *bytes.Buffer) {\n" +
+		"\tfmt.Println(context.Background())\n" +
+		"}\n" +
+		"\n"
+
+	fspec := NewFileSpec("foo")
+	fspec.InitializationPackage(&ImportSpec{Package: "image/png"})
+	fspec.CodeBlock(NewFuncSpec("blah").
+		Parameter("a", TypeReferenceFromInstance((*io.Reader)(nil))).
+		Parameter("b", TypeReferenceFromInstance(&bytes.Buffer{})).
+		Statement("$T($T())", TypeReferenceFromInstance(fmt.Println), TypeReferenceFromInstance(context.Background)))
+
+	for i := 0; i < 10; i++ {
+		c.Assert(fspec.String(), Equals, expected)
+	}
+}
+
+func (f *FilesSuite) TestFileLocalImportPrefix(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"bytes\"\n" +
+		"\n" +
+		"\t\"golang.org/x/net/context\"\n" +
+		")\n" +
+		"\n" +
+		"func blah(a *bytes.Buffer) {\n" +
+		"\tcontext.Background()\n" +
+		"}\n" +
+		"\n"
+
+	fspec := NewFileSpec("foo").LocalImportPrefix("golang.org/x/")
+	fspec.CodeBlock(NewFuncSpec("blah").
+		Parameter("a", TypeReferenceFromInstance(&bytes.Buffer{})).
+		Statement("$T()", TypeReferenceFromInstance(context.Background)))
+
+	c.Assert(fspec.String(), Equals, expected)
+	c.Assert(fspec.ImportLayout.LocalPrefixes, DeepEquals, []string{"golang.org/x/"})
+}
+
+func (f *FilesSuite) TestFileImportConflicts(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\trand2 \"crypto/rand\"\n" +
+		"\t\"math/rand\"\n" +
+		")\n" +
+		"\n" +
+		"func a() int {\n" +
+		"\treturn rand.Int()\n" +
+		"}\n" +
+		"\n" +
+		"func b(r *rand.Rand) {\n" +
+		"\trand2.Read(nil)\n" +
+		"}\n" +
+		"\n"
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(NewFuncSpec("a").
+		ResultParameter("", Int).
+		Statement("return $T()", TypeReferenceFromInstance(mrand.Int)))
+	fspec.CodeBlock(NewFuncSpec("b").
+		Parameter("r", TypeReferenceFromInstance(&mrand.Rand{})).
+		Statement("$T(nil)", TypeReferenceFromInstance(crand.Read)))
+
+	c.Assert(fspec.String(), Equals, expected)
+}
+
+func (f *FilesSuite) TestFileImportConflictWithAlias(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\tbytes2 \"bytes\"\n" +
+		"\tbytes \"fmt\"\n" +
+		")\n" +
+		"\n" +
+		"func a(b *bytes2.Buffer) {\n" +
+		"\tbytes.Println()\n" +
+		"}\n" +
+		"\n"
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(NewFuncSpec("a").
+		Parameter("b", TypeReferenceFromInstance(&bytes.Buffer{})).
+		Statement("$T()", TypeReferenceFromInstanceWithAlias(fmt.Println, "bytes")))
+
+	c.Assert(fspec.String(), Equals, expected)
+}
+
+func (f *FilesSuite) TestFileImportConflictsInStructsAndInterfaces(c *C) {
+	expected := "" +
+		"func read() {\n" +
+		"\trand.Read(nil)\n" +
+		"}\n" +
+		"\n" +
+		"type foo struct {\n" +
+		"\ta *rand2.Rand\n" +
+		"}\n" +
+		"\n" +
+		"type bar interface {\n" +
+		"\tbaz(r io.Reader, s *rand2.Rand)\n" +
+		"}\n" +
+		"\n"
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(NewFuncSpec("read").Statement("$T(nil)", TypeReferenceFromInstance(crand.Read)))
+	fspec.CodeBlock(NewStructSpec("foo").Field("a", TypeReferenceFromInstance(&mrand.Rand{})))
+	fspec.CodeBlock(NewInterfaceSpec("bar").Method(NewFuncSpec("baz").
+		Parameter("r", TypeReferenceFromInstance((*io.Reader)(nil))).
+		Parameter("s", TypeReferenceFromInstance(&mrand.Rand{}))))
+
+	actual := fspec.String()
+	c.Assert(strings.Contains(actual, "\trand2 \"math/rand\"\n"), Equals, true)
+	c.Assert(strings.HasSuffix(actual, expected), Equals, true)
+}
+
+func (f *FilesSuite) TestFilePackageNames(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\tcheck \"gopkg.in/check.v1\"\n" +
+		"\tclient \"k8s.io/client-go/kubernetes\"\n" +
+		")\n" +
+		"\n" +
+		"func blah(c *check.C, k *client.Clientset) {\n" +
+		"}\n" +
+		"\n"
+
+	fspec := NewFileSpec("foo").PackageName("k8s.io/client-go/kubernetes", "client")
+	fspec.CodeBlock(NewFuncSpec("blah").
+		Parameter("c", TypeReferenceFromInstance(&C{})).
+		Parameter("k", &typeReferenceValue{
+			Import: &ImportSpec{Package: "k8s.io/client-go/kubernetes", Qualified: true},
+			Name:   "Clientset",
+			prefix: "*",
+		}))
+
+	c.Assert(fspec.String(), Equals, expected)
+}
+
+func (f *FilesSuite) TestFileRender(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"fmt\"\n" +
+		")\n" +
+		"\n" +
+		"func blah(a int) {\n" +
+		"\tif a > 0 {\n" +
+		"\t\tfmt.Println(a)\n" +
+		"\t}\n" +
+		"}\n"
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(NewFuncSpec("blah").
+		Parameter("a", Int).
+		Statement("if a > 0 {").
+		Statement("$T(a)", TypeReferenceFromInstance(fmt.Println)).
+		Statement("}"))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (f *FilesSuite) TestFileRenderError(c *C) {
+	fspec := NewFileSpec("foo")
+	fspec.GlobalVariable("a", Int, "$L", 1)
+	fspec.CodeBlock(NewFuncSpec("blah").
+		Statement("a := 1").
+		Statement("a = = $L", 2))
+
+	actual, err := fspec.Render()
+	c.Assert(actual, Equals, "")
+
+	formatErr, ok := err.(*FormatError)
+	c.Assert(ok, Equals, true)
+	c.Check(formatErr.Line, Equals, 7)
+	c.Check(formatErr.Spec, Equals, "func blah")
+	c.Check(formatErr.Code, Equals, "\ta = = 2")
+	c.Check(formatErr.Source, Equals, fspec.String())
+	c.Check(err.Error(), Equals, "7:6: expected operand, found '=' (in func blah): a = = 2")
+}
+
+func (f *FilesSuite) TestFileSpecsFromOtherPackages(c *C) {
+	expected := "" +
+		"package api\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"example.com/app/models\"\n" +
+		"\tstore \"example.com/app/store-go\"\n" +
+		")\n" +
+		"\n" +
+		"func GetUser(s store.Store, id models.ID) (*models.User, error) {\n" +
+		"}\n"
+
+	user := NewStructSpec("User").InPackage("example.com/app/models", "")
+	user.Field("Name", String)
+	id := NewTypeAliasSpec("ID", Int64).InPackage("example.com/app/models", "models")
+	st := NewInterfaceSpec("Store").InPackage("example.com/app/store-go", "store")
+
+	fspec := NewFileSpec("api").InPackage("example.com/app/api")
+	fspec.CodeBlock(NewFuncSpec("GetUser").
+		Parameter("s", st).
+		Parameter("id", id).
+		ResultParameter("", PointerTo(user)).
+		ResultParameter("", Error))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (f *FilesSuite) TestFileSpecsFromOtherPackagesInStatements(c *C) {
+	expected := "" +
+		"package api\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"example.com/app/models\"\n" +
+		")\n" +
+		"\n" +
+		"func blah() {\n" +
+		"\t_ = models.User{}\n" +
+		"}\n"
+
+	user := NewStructSpec("User").InPackage("example.com/app/models", "models")
+	user.Field("Body", TypeReferenceFromInstance((*io.Reader)(nil)))
+
+	fspec := NewFileSpec("api")
+	fspec.CodeBlock(NewFuncSpec("blah").Statement("_ = $T{}", user))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (f *FilesSuite) TestFileSpecsInOwnPackage(c *C) {
+	expected := "" +
+		"package models\n" +
+		"\n" +
+		"type User struct {\n" +
+		"\tFriends []*User\n" +
+		"}\n" +
+		"\n" +
+		"func (u *User) Self() *User {\n" +
+		"\treturn u\n" +
+		"}\n" +
+		"\n" +
+		"func Users() map[string]User {\n" +
+		"}\n"
+
+	user := NewStructSpec("User").InPackage("example.com/app/models", "")
+	user.Field("Friends", SliceOf(PointerTo(user)))
+	self := user.Method("Self", "u", true)
+	self.ResultParameter("", PointerTo(user)).Statement("return u")
+	user.AttachMethod(self)
+
+	fspec := NewFileSpec("models").InPackage("example.com/app/models")
+	fspec.CodeBlock(user)
+	fspec.CodeBlock(NewFuncSpec("Users").ResultParameter("", MapOf(String, user)))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (f *FilesSuite) TestFileSuppressesSelfImports(c *C) {
+	expected := "" +
+		"package bytes\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"io\"\n" +
+		")\n" +
+		"\n" +
+		"func (b *Buffer) Copy(w io.Writer) (*Buffer, error) {\n" +
+		"\tc := NewBuffer(b.Bytes())\n" +
+		"\t_, err := c.WriteTo(w)\n" +
+		"\treturn c, err\n" +
+		"}\n" +
+		"\n" +
+		"var readers map[*Reader]Buffer = map[*Reader]Buffer{}\n"
+
+	buffer := TypeReferenceFromInstanceWithAlias(&bytes.Buffer{}, "b2")
+	fspec := NewFileSpec("bytes").InPackage("bytes")
+	fspec.InitializationPackage(&ImportSpec{Package: "bytes", Alias: "_"})
+	method := NewMethodSpec("Copy", "b", TypeReferenceFromInstance(&bytes.Buffer{}))
+	method.Parameter("w", TypeReferenceFromInstance((*io.Writer)(nil))).
+		ResultParameter("", buffer).
+		ResultParameter("", Error).
+		Statement("c := $T(b.Bytes())", TypeReferenceFromInstance(bytes.NewBuffer)).
+		Statement("_, err := c.WriteTo(w)").
+		Statement("return c, err")
+	fspec.CodeBlock(method)
+	readers := MapOf(TypeReferenceFromInstance(&bytes.Reader{}), TypeReferenceFromInstance(bytes.Buffer{}))
+	fspec.GlobalVariable("readers", readers, "$T{}", readers)
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (f *FilesSuite) TestFileImportModes(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"bytes\"\n" +
+		"\tb \"bytes\"\n" +
+		"\n" +
+		"\t. \"gopkg.in/check.v1\"\n" +
+		"\n" +
+		"\t_ \"image/png\"\n" +
+		")\n" +
+		"\n" +
+		"func blah(a *bytes.Buffer, b *b.Buffer, c *C) {\n" +
+		"}\n" +
+		"\n" +
+		"func blah2(c *C) {\n" +
+		"}\n"
+
+	check := NewImportSpec("gopkg.in/check.v1", ImportDot, "")
+	fspec := NewFileSpec("foo")
+	fspec.InitializationPackage(&ImportSpec{Package: "image/png"})
+	fspec.CodeBlock(NewFuncSpec("blah").
+		Parameter("a", PointerTo(NewTypeReference("bytes", "Buffer"))).
+		Parameter("b", TypeReferenceFromInstanceWithAlias(&bytes.Buffer{}, "b")).
+		Parameter("c", PointerTo(NewTypeReferenceWithImport(check, "C"))))
+	fspec.CodeBlock(NewFuncSpec("blah2").
+		Parameter("c", PointerTo(NewTypeReferenceWithImport(&ImportSpec{Package: "gopkg.in/check.v1", Qualified: true, Alias: "."}, "C"))))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
@@ -3,12 +3,15 @@ package poet
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 )
 
 // FuncSpec represents information needed to write a function
 type FuncSpec struct {
 	Name             string
 	Comment          string
+	LineComment      string // LineComment is written after the signature of an interface method, and is not written for a function or method declaration
+	TypeParameters   []*TypeParameter
 	Parameters       []IdentifierParameter
 	ResultParameters []IdentifierParameter
 	Statements       []Statement
@@ -28,22 +31,27 @@ func NewFuncSpec(name string) *FuncSpec {
 
 // String returns a string representation of the function
 func (f *FuncSpec) String() string {
-	writer := newCodeWriter()
+	return mustWriteCodeBlock(f)
+}
 
-	if f.Comment != "" {
-		writer.WriteCodeBlock(Comment(f.Comment))
-	}
+// Validate returns an ErrorList of the function's statements that cannot be written, or nil.
+func (f *FuncSpec) Validate() error {
+	_, err := writeCodeBlock(f)
+	return err
+}
 
-	signature, args := f.Signature()
-	writer.WriteStatement(newStatement(0, 1, fmt.Sprintf("func %s {", signature), args...))
+// GetStatements returns the function's comment, declaration and body as statements.
+func (f *FuncSpec) GetStatements() []Statement {
+	var statements []Statement
 
-	for _, st := range f.Statements {
-		writer.WriteStatement(st)
-	}
+	statements = append(statements, generated(Comment(f.Comment).GetStatements()...)...)
 
-	writer.WriteStatement(newStatement(-1, 0, "}"))
+	signature, args := f.Signature()
+	statements = append(statements, generated(newStatement(0, 1, fmt.Sprintf("func %s {", signature), args...))...)
+	statements = append(statements, f.Statements...)
+	statements = append(statements, generated(newStatement(-1, 0, "}"))...)
 
-	return writer.String()
+	return statements
 }
 
 // Signature returns a format string and slice of arguments for the function's signature, not
@@ -53,17 +61,20 @@ func (f *FuncSpec) Signature() (string, []interface{}) {
 	b := bytes.Buffer{}
 	arguments := []interface{}{}
 
-	// write the function name
+	// write the function name and any type parameters
 	b.WriteString(f.Name)
+	format, args := writeTypeParameters(f.TypeParameters)
+	b.WriteString(format)
+	arguments = append(arguments, args...)
 	b.WriteString("(")
 
 	// write each parameter and collect any arguments
-	format, args := writeParameters(f.Parameters)
+	format, args = writeParameters(f.Parameters, "parameter")
 	b.WriteString(format)
 	b.WriteString(")")
 	arguments = append(arguments, args...)
 
-	format, args = writeParameters(f.ResultParameters)
+	format, args = writeParameters(f.ResultParameters, "result")
 	l := len(f.ResultParameters)
 
 	// if there is only one parameter and the parameter is unnamed, do not wrap it in parens
@@ -80,7 +91,9 @@ func (f *FuncSpec) Signature() (string, []interface{}) {
 	return b.String(), arguments
 }
 
-func writeParameters(params []IdentifierParameter) (string, []interface{}) {
+// writeParameters returns a format string and arguments for the parameters. A parameter without
+// a type is reported by name, or by position if it has none, e.g. "result 1 has no type".
+func writeParameters(params []IdentifierParameter, kind string) (string, []interface{}) {
 	b := bytes.Buffer{}
 	args := []interface{}{}
 
@@ -93,7 +106,15 @@ func writeParameters(params []IdentifierParameter) (string, []interface{}) {
 
 		// add its type
 		b.WriteString("$T")
-		args = append(args, p.Type)
+		if p.Type == nil {
+			name := p.Name
+			if name == "" {
+				name = strconv.Itoa(i + 1)
+			}
+			args = append(args, errorArgument{fmt.Errorf("%s %s has no type", kind, name)})
+		} else {
+			args = append(args, p.Type)
+		}
 
 		// if the argument is variadic, add the '...', will never happen for
 		// result parameters
@@ -111,24 +132,17 @@ func writeParameters(params []IdentifierParameter) (string, []interface{}) {
 }
 
 // GetImports returns a slice of imports that this function needs, including
-// parameters, result parameters, and statements within the function
+// type parameter constraints, parameters, result parameters, and statements within the function
 func (f *FuncSpec) GetImports() []Import {
-	packages := []Import{}
-
-	for _, st := range f.Statements {
-		for _, arg := range st.Arguments {
-			if asTypeRef, ok := arg.(TypeReference); ok {
-				packages = append(packages, asTypeRef.GetImports()...)
-			}
-		}
-	}
+	packages := statementImports(f.Statements)
+	packages = append(packages, typeParameterImports(f.TypeParameters)...)
 
 	for _, param := range f.Parameters {
-		packages = append(packages, param.Type.GetImports()...)
+		packages = append(packages, getImports(param.Type)...)
 	}
 
 	for _, param := range f.ResultParameters {
-		packages = append(packages, param.Type.GetImports()...)
+		packages = append(packages, getImports(param.Type)...)
 	}
 
 	return packages
@@ -141,6 +155,20 @@ func (f *FuncSpec) Statement(format string, args ...interface{}) *FuncSpec {
 	return f
 }
 
+// AddStatements appends statements to the function, such as those from a CodeBlockBuilder.
+func (f *FuncSpec) AddStatements(statements ...Statement) *FuncSpec {
+	f.Statements = append(f.Statements, statements...)
+
+	return f
+}
+
+// AddCode appends the statements of the code to the function.
+func (f *FuncSpec) AddCode(c *Code) *FuncSpec {
+	f.Statements = append(f.Statements, c.Statements...)
+
+	return f
+}
+
 // BlockStart is a convenient method to append a statement that marks the start of a
 // block of code.
 func (f *FuncSpec) BlockStart(format string, args ...interface{}) *FuncSpec {
@@ -157,6 +185,14 @@ func (f *FuncSpec) BlockEnd() *FuncSpec {
 	return f
 }
 
+// TypeParameter appends a type parameter to the function, making it generic. The type
+// parameter can then be used as the type of the function's parameters.
+func (f *FuncSpec) TypeParameter(p *TypeParameter) *FuncSpec {
+	f.TypeParameters = append(f.TypeParameters, p)
+
+	return f
+}
+
 // Parameter is a convenient method to append a parameter to the function
 func (f *FuncSpec) Parameter(name string, spec TypeReference) *FuncSpec {
 	f.Parameters = append(f.Parameters, IdentifierParameter{
@@ -194,6 +230,14 @@ func (f *FuncSpec) ResultParameter(name string, spec TypeReference) *FuncSpec {
 	return f
 }
 
+// FunctionLineComment adds a comment after the function's signature, for use as an interface
+// method. It is not written when the function is declared on its own or as a method, since
+// the signature's line ends with the opening brace of the body.
+func (f *FuncSpec) FunctionLineComment(comment string) *FuncSpec {
+	f.LineComment = comment
+	return f
+}
+
 // FunctionComment adds a comment to the function
 func (f *FuncSpec) FunctionComment(comment string) *FuncSpec {
 	f.Comment = comment
@@ -203,3 +203,37 @@ func (f *FunctionsSuite) TestFunctionImportsWithAlias(c *C) {
 	actual := fnc.GetImports()
 	c.Assert(actual, DeepEquals, expected)
 }
+
+func (f *FunctionsSuite) TestFunctionValidate(c *C) {
+	fnc := NewFuncSpec("foo").
+		FunctionComment("foo does things").
+		Statement("$T()", TypeReferenceFromInstance(fmt.Println)).
+		Statement("$T()", "fmt.Println")
+
+	err := fnc.Validate()
+	c.Assert(err, ErrorMatches, `func foo: statement 1 \("\$T\(\)"\): \$T must implement TypeReference, got type=string "fmt.Println"`)
+	c.Assert(NewFuncSpec("foo").Validate(), IsNil)
+}
+
+func (f *FunctionsSuite) TestFunctionValidateNilParameter(c *C) {
+	fnc := NewFuncSpec("foo").Parameter("a", nil)
+
+	c.Assert(fnc.GetImports(), HasLen, 0)
+	c.Assert(fnc.Validate(), ErrorMatches, "func foo: parameter a has no type")
+	c.Assert(NewFuncSpec("foo").ResultParameter("", nil).Validate(), ErrorMatches, "func foo: result 1 has no type")
+
+	st := NewStructSpec("S")
+	m := st.Method("Do", "s", false)
+	m.Parameter("a", Int).VariadicParameter("b", nil)
+	st.Field("a", nil).Field("", nil).AttachMethod(m)
+	c.Assert(st.Validate(), ErrorMatches, "struct S: field a has no type\n"+
+		"struct S: embedded field has no type\n"+
+		"method Do: parameter b has no type")
+}
+
+func (f *FunctionsSuite) TestFunctionLineCommentOnlyForInterfaceMethods(c *C) {
+	fnc := NewFuncSpec("foo").FunctionLineComment("does things")
+
+	c.Assert(fnc.String(), Equals, "func foo() {\n}\n")
+	c.Assert(NewInterfaceSpec("Foo").Method(fnc).String(), Equals, "type Foo interface {\n\tfoo() // does things\n}\n")
+}
@@ -0,0 +1,135 @@
+package poet
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// FuncTypeOf creates a TypeReference for a func type with the given parameter and result types,
+// e.g. func(string, int) error.
+func FuncTypeOf(params []TypeReference, results []TypeReference) TypeReference {
+	return &typeReferenceFuncType{
+		Params:  unnamedParameters(params),
+		Results: unnamedParameters(results),
+	}
+}
+
+// FuncTypeFromParameters creates a TypeReference for a func type with the given parameters and
+// results, e.g. func(ctx context.Context, name string) error. As in FuncSpec, parameters are
+// written with their names if they have them, and the type of a variadic parameter is the type
+// of its elements.
+func FuncTypeFromParameters(params []IdentifierParameter, results []IdentifierParameter) TypeReference {
+	return &typeReferenceFuncType{
+		Params:  params,
+		Results: results,
+	}
+}
+
+// funcTypeFromReflectType creates a TypeReference for the func type.
+func funcTypeFromReflectType(t reflect.Type) TypeReference {
+	f := &typeReferenceFuncType{}
+
+	for i := 0; i < t.NumIn(); i++ {
+		p := IdentifierParameter{}
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			p.Type = TypeReferenceFromReflectType(t.In(i).Elem())
+			p.Variadic = true
+		} else {
+			p.Type = TypeReferenceFromReflectType(t.In(i))
+		}
+		f.Params = append(f.Params, p)
+	}
+
+	for i := 0; i < t.NumOut(); i++ {
+		f.Results = append(f.Results, IdentifierParameter{
+			Identifier: Identifier{Type: TypeReferenceFromReflectType(t.Out(i))},
+		})
+	}
+
+	return f
+}
+
+func unnamedParameters(types []TypeReference) []IdentifierParameter {
+	params := make([]IdentifierParameter, len(types))
+	for i, t := range types {
+		params[i].Type = t
+	}
+	return params
+}
+
+// typeReferenceFuncType is a func type, written with its parameters and results.
+type typeReferenceFuncType struct {
+	Params  []IdentifierParameter
+	Results []IdentifierParameter
+}
+
+var _ TypeReference = (*typeReferenceFuncType)(nil)
+
+func (t *typeReferenceFuncType) GetImports() []Import {
+	imports := []Import{}
+	for _, p := range t.Params {
+		imports = append(imports, getImports(p.Type)...)
+	}
+	for _, r := range t.Results {
+		imports = append(imports, getImports(r.Type)...)
+	}
+	return imports
+}
+
+func (t *typeReferenceFuncType) elems() []TypeReference {
+	return append(parameterTypes(t.Params), parameterTypes(t.Results)...)
+}
+
+func (t *typeReferenceFuncType) GetName() string {
+	return t.getNameIn(nil)
+}
+
+func (t *typeReferenceFuncType) getNameIn(names *importNames) string {
+	b := bytes.Buffer{}
+
+	b.WriteString("func(")
+	b.WriteString(parameterNames(t.Params, names))
+	b.WriteString(")")
+
+	// a single unnamed result is not wrapped in parens
+	if len(t.Results) == 1 && t.Results[0].Name == "" {
+		b.WriteString(" ")
+		b.WriteString(parameterNames(t.Results, names))
+	} else if len(t.Results) > 0 {
+		b.WriteString(" (")
+		b.WriteString(parameterNames(t.Results, names))
+		b.WriteString(")")
+	}
+
+	return b.String()
+}
+
+// parameterTypes returns the types of the parameters.
+func parameterTypes(params []IdentifierParameter) []TypeReference {
+	types := make([]TypeReference, len(params))
+	for i, p := range params {
+		types[i] = p.Type
+	}
+	return types
+}
+
+// parameterNames returns the comma separated parameters, as written in a func type.
+func parameterNames(params []IdentifierParameter, names *importNames) string {
+	b := bytes.Buffer{}
+
+	for i, p := range params {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if p.Name != "" {
+			b.WriteString(p.Name)
+			b.WriteString(" ")
+		}
+		if p.Variadic {
+			b.WriteString("...")
+		}
+		b.WriteString(typeName(p.Type, names))
+	}
+
+	return b.String()
+}
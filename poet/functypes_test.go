@@ -0,0 +1,100 @@
+package poet
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+	. "gopkg.in/check.v1"
+)
+
+type FuncTypesSuite struct{}
+
+var _ = Suite(&FuncTypesSuite{})
+
+func (s *FuncTypesSuite) TestFuncTypeFromParameters(c *C) {
+	ctx := NewTypeReference("context", "Context")
+
+	typeRef := FuncTypeFromParameters(
+		[]IdentifierParameter{
+			{Identifier: Identifier{Name: "ctx", Type: ctx}},
+			{Identifier: Identifier{Name: "names", Type: String}, Variadic: true},
+		},
+		[]IdentifierParameter{
+			{Identifier: Identifier{Name: "n", Type: Int}},
+			{Identifier: Identifier{Name: "err", Type: Error}},
+		},
+	)
+	c.Assert(typeRef.GetName(), Equals, "func(ctx context.Context, names ...string) (n int, err error)")
+
+	typeRef = FuncTypeFromParameters(
+		[]IdentifierParameter{{Identifier: Identifier{Type: String}}},
+		[]IdentifierParameter{{Identifier: Identifier{Name: "ok", Type: Bool}}},
+	)
+	c.Assert(typeRef.GetName(), Equals, "func(string) (ok bool)")
+}
+
+func (s *FuncTypesSuite) TestFuncTypeFromInstance(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{func(s string) error { return nil }, "func(string) error"},
+		{func() func(int) {
+			return func(int) {}
+		}(), "func(int)"},
+		{(&bytes.Buffer{}).Write, "func([]uint8) (int, error)"},
+		{(*bytes.Buffer).WriteString, "func(*bytes.Buffer, string) (int, error)"},
+		{(func(context.Context, ...interface{}))(nil), "func(context.Context, ...interface{})"},
+		{http.NotFound, "http.NotFound"},
+		{strings.ToUpper, "strings.ToUpper"},
+	}
+
+	for _, v := range values {
+		c.Check(TypeReferenceFromInstance(v.value).GetName(), Equals, v.expected)
+	}
+}
+
+func (s *FuncTypesSuite) TestFuncTypeImports(c *C) {
+	expected := []Import{
+		&ImportSpec{Package: "net/http", Qualified: true},
+		&ImportSpec{Package: "net/http", Qualified: true},
+		&ImportSpec{Package: "fmt", Qualified: true},
+	}
+
+	typeRef := TypeReferenceFromInstance(func(http.ResponseWriter, *http.Request) fmt.Stringer { return nil })
+
+	c.Assert(typeRef.GetImports(), DeepEquals, expected)
+}
+
+func (s *FuncTypesSuite) TestFuncTypeInFile(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"context\"\n" +
+		"\t\"net/http\"\n" +
+		")\n" +
+		"\n" +
+		"type handlers struct {\n" +
+		"\tserve func(http.ResponseWriter, *http.Request)\n" +
+		"\tdone  func(ctx context.Context) error\n" +
+		"}\n"
+
+	done := FuncTypeFromParameters(
+		[]IdentifierParameter{{Identifier: Identifier{Name: "ctx", Type: NewTypeReference("context", "Context")}}},
+		[]IdentifierParameter{{Identifier: Identifier{Type: Error}}},
+	)
+	st := NewStructSpec("handlers").
+		Field("serve", TypeReferenceFromInstance((http.HandlerFunc)(nil).ServeHTTP)).
+		Field("done", done)
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(st)
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
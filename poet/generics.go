@@ -0,0 +1,128 @@
+package poet
+
+import (
+	"bytes"
+)
+
+var (
+	// Any A TypeReference for the any constraint
+	Any TypeReference = &typeReferenceValue{Name: "any"}
+	// Comparable A TypeReference for the comparable constraint
+	Comparable TypeReference = &typeReferenceValue{Name: "comparable"}
+)
+
+// TypeParameter represents a type parameter of a generic function or type. A TypeParameter is
+// also a TypeReference, so it can be used as the type of parameters, fields and type arguments
+// within the declaration it belongs to.
+type TypeParameter struct {
+	Name       string        // Name of the type parameter (e.g. in "[T any]", T)
+	Constraint TypeReference // Constraint that type arguments must satisfy, e.g. Any
+}
+
+var _ TypeReference = (*TypeParameter)(nil)
+
+// NewTypeParameter returns a type parameter with the given name and constraint.
+func NewTypeParameter(name string, constraint TypeReference) *TypeParameter {
+	return &TypeParameter{
+		Name:       name,
+		Constraint: constraint,
+	}
+}
+
+// GetName returns the name of the type parameter.
+func (p *TypeParameter) GetName() string {
+	return p.Name
+}
+
+// GetImports returns nothing, since a type parameter is declared by the spec using it. The
+// constraint's imports are the imports of that spec.
+func (p *TypeParameter) GetImports() []Import {
+	return nil
+}
+
+// Approx creates a TypeReference for the approximation ~T of a type, for use as a term of an
+// interface's union. It matches every type whose underlying type is t.
+func Approx(t TypeReference) TypeReference {
+	return &typeReferenceElem{
+		prefix: "~",
+		Elem:   t,
+	}
+}
+
+// writeTypeParameters returns a format string and arguments for a type parameter list, e.g.
+// "[K comparable, V any]", or an empty format string if there are no type parameters.
+func writeTypeParameters(params []*TypeParameter) (string, []interface{}) {
+	if len(params) == 0 {
+		return "", nil
+	}
+
+	b := bytes.Buffer{}
+	args := []interface{}{}
+
+	b.WriteString("[")
+	for i, p := range params {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("$L $T")
+		args = append(args, p.Name, p.Constraint)
+	}
+	b.WriteString("]")
+
+	return b.String(), args
+}
+
+// typeParameterImports returns the imports used by the constraints of the type parameters.
+func typeParameterImports(params []*TypeParameter) []Import {
+	imports := []Import{}
+	for _, p := range params {
+		imports = append(imports, getImports(p.Constraint)...)
+	}
+	return imports
+}
+
+// typeParameterReferences returns the type parameters as type arguments, for a generic type
+// referring to itself, like the receiver of its methods.
+func typeParameterReferences(params []*TypeParameter) []TypeReference {
+	refs := make([]TypeReference, len(params))
+	for i, p := range params {
+		refs[i] = p
+	}
+	return refs
+}
+
+// Instantiate creates a TypeReference for a generic type instantiated with the given type
+// arguments, e.g. Set[string] or maps.Map[K, V].
+func Instantiate(generic TypeReference, args ...TypeReference) TypeReference {
+	return &typeReferenceInstance{
+		Generic: generic,
+		Args:    args,
+	}
+}
+
+type typeReferenceInstance struct {
+	Generic TypeReference
+	Args    []TypeReference
+}
+
+var _ TypeReference = (*typeReferenceInstance)(nil)
+
+func (t *typeReferenceInstance) GetImports() []Import {
+	imports := getImports(t.Generic)
+	for _, arg := range t.Args {
+		imports = append(imports, getImports(arg)...)
+	}
+	return imports
+}
+
+func (t *typeReferenceInstance) elems() []TypeReference {
+	return append([]TypeReference{t.Generic}, t.Args...)
+}
+
+func (t *typeReferenceInstance) GetName() string {
+	return t.getNameIn(nil)
+}
+
+func (t *typeReferenceInstance) getNameIn(names *importNames) string {
+	return typeName(t.Generic, names) + "[" + typeNames(t.Args, names) + "]"
+}
@@ -0,0 +1,171 @@
+package poet
+
+import (
+	"bytes"
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+type GenericsSuite struct{}
+
+var _ = Suite(&GenericsSuite{})
+
+func (s *GenericsSuite) TestGenericFunction(c *C) {
+	expected := "" +
+		"func Max[T comparable, U any](a T, b T, u U) T {\n" +
+		"}\n"
+
+	t := NewTypeParameter("T", Comparable)
+	u := NewTypeParameter("U", Any)
+	f := NewFuncSpec("Max").
+		TypeParameter(t).
+		TypeParameter(u).
+		Parameter("a", t).
+		Parameter("b", t).
+		Parameter("u", u).
+		ResultParameter("", t)
+
+	c.Assert(f.String(), Equals, expected)
+}
+
+func (s *GenericsSuite) TestGenericFunctionImportsConstraints(c *C) {
+	expected := "" +
+		"func Join[T fmt.Stringer](values [2]T) string {\n" +
+		"}\n"
+
+	t := NewTypeParameter("T", TypeReferenceFromInstance((*fmt.Stringer)(nil)))
+	f := NewFuncSpec("Join").
+		TypeParameter(t).
+		Parameter("values", ArrayOf(2, t)).
+		ResultParameter("", String)
+
+	c.Assert(f.String(), Equals, expected)
+	c.Assert(f.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "fmt", Qualified: true},
+		(*ImportSpec)(nil),
+	})
+}
+
+func (s *GenericsSuite) TestGenericStructWithMethods(c *C) {
+	expected := "" +
+		"type Set[T comparable] struct {\n" +
+		"\tfirst T\n" +
+		"\tnext *Set[T]\n" +
+		"}\n" +
+		"\n" +
+		"func (s *Set[T]) First() T {\n" +
+		"\treturn s.first\n" +
+		"}\n" +
+		"\n" +
+		"func (s Set[T]) Next() *Set[T] {\n" +
+		"\treturn s.next\n" +
+		"}\n" +
+		"\n"
+
+	t := NewTypeParameter("T", Comparable)
+	set := NewStructSpec("Set").TypeParameter(t)
+	set.Field("first", t)
+	set.Field("next", PointerTo(Instantiate(set, t)))
+	first := set.Method("First", "s", true)
+	first.ResultParameter("", t).Statement("return s.first")
+	set.AttachMethod(first)
+	set.AttachMethod(set.MethodFromFunction("s", false, NewFuncSpec("Next").
+		ResultParameter("", PointerTo(Instantiate(set, t))).
+		Statement("return s.next")))
+
+	c.Assert(set.String(), Equals, expected)
+}
+
+func (s *GenericsSuite) TestGenericInterface(c *C) {
+	expected := "" +
+		"type Container[K comparable, V fmt.Stringer] interface {\n" +
+		"\tGet(key K) V\n" +
+		"}\n"
+
+	k := NewTypeParameter("K", Comparable)
+	v := NewTypeParameter("V", TypeReferenceFromInstance((*fmt.Stringer)(nil)))
+	i := NewInterfaceSpec("Container").
+		TypeParameter(k).
+		TypeParameter(v).
+		Method(NewFuncSpec("Get").Parameter("key", k).ResultParameter("", v))
+
+	c.Assert(i.String(), Equals, expected)
+	c.Assert(i.GetImports()[1], DeepEquals, &ImportSpec{Package: "fmt", Qualified: true})
+}
+
+func (s *GenericsSuite) TestInstantiate(c *C) {
+	set := NewStructSpec("Set").TypeParameter(NewTypeParameter("T", Comparable))
+
+	typeRef := Instantiate(set, TypeReferenceFromInstance(&bytes.Buffer{}))
+	c.Assert(typeRef.GetName(), Equals, "Set[*bytes.Buffer]")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		(*ImportSpec)(nil),
+		&ImportSpec{Package: "bytes", Qualified: true},
+	})
+
+	pair := NewTypeReference("example.com/maps", "Map")
+	typeRef = Instantiate(pair, String, Instantiate(set, Int))
+	c.Assert(typeRef.GetName(), Equals, "maps.Map[string, Set[int]]")
+	c.Assert(typeRef.GetImports()[0], DeepEquals, &ImportSpec{Package: "example.com/maps", Qualified: true})
+}
+
+func (s *GenericsSuite) TestGenericFile(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"fmt\"\n" +
+		")\n" +
+		"\n" +
+		"type Box[T fmt.Stringer] struct {\n" +
+		"\tvalue T\n" +
+		"}\n" +
+		"\n" +
+		"func (b *Box[T]) String() string {\n" +
+		"\treturn b.value.String()\n" +
+		"}\n"
+
+	t := NewTypeParameter("T", TypeReferenceFromInstance((*fmt.Stringer)(nil)))
+	box := NewStructSpec("Box").TypeParameter(t)
+	box.Field("value", t)
+	str := box.Method("String", "b", true)
+	str.ResultParameter("", String).Statement("return b.value.String()")
+	box.AttachMethod(str)
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(box)
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (s *GenericsSuite) TestConstraintInterface(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"type Number interface {\n" +
+		"\t~int | ~int64 | ~float64\n" +
+		"}\n" +
+		"\n" +
+		"func Sum[T Number](a T, b T) T {\n" +
+		"\treturn a + b\n" +
+		"}\n"
+
+	number := NewInterfaceSpec("Number").Union(Approx(Int), Approx(Int64), Approx(Float64))
+	t := NewTypeParameter("T", number)
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(number)
+	fspec.CodeBlock(NewFuncSpec("Sum").
+		TypeParameter(t).
+		Parameter("a", t).
+		Parameter("b", t).
+		ResultParameter("", t).
+		Statement("return a + b"))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
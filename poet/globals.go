@@ -38,6 +38,24 @@ func (g *VariableGrouping) Constant(name string, typ TypeReference, format strin
 	return g
 }
 
+// EntryComment adds a comment above the variable or constant most recently added to this
+// variable grouping.
+func (g *VariableGrouping) EntryComment(comment string) *VariableGrouping {
+	if len(g.Variables) != 0 {
+		g.Variables[len(g.Variables)-1].Comment = comment
+	}
+	return g
+}
+
+// EntryLineComment adds a comment to the end of the line of the variable or constant most
+// recently added to this variable grouping.
+func (g *VariableGrouping) EntryLineComment(comment string) *VariableGrouping {
+	if len(g.Variables) != 0 {
+		g.Variables[len(g.Variables)-1].LineComment = comment
+	}
+	return g
+}
+
 // GetImports returns a slice of imports that this variable grouping uses.
 func (g *VariableGrouping) GetImports() []Import {
 	imports := []Import{}
@@ -48,11 +66,13 @@ func (g *VariableGrouping) GetImports() []Import {
 }
 
 func (g *VariableGrouping) String() string {
-	w := newCodeWriter()
-	for _, s := range g.GetStatements() {
-		w.WriteStatement(s)
-	}
-	return w.String()
+	return mustWriteCodeBlock(g)
+}
+
+// Validate returns an ErrorList of the grouping's values that cannot be written, or nil.
+func (g *VariableGrouping) Validate() error {
+	_, err := writeCodeBlock(g)
+	return err
 }
 
 func (g *VariableGrouping) GetStatements() []Statement {
@@ -75,7 +95,7 @@ func (g *VariableGrouping) GetStatements() []Statement {
 	}
 	statements = append(statements, globalsAsStatements("var", vars)...)
 
-	return statements
+	return generated(statements...)
 }
 
 func globalsAsStatements(groupName string, vars []*Variable) []Statement {
@@ -83,28 +103,29 @@ func globalsAsStatements(groupName string, vars []*Variable) []Statement {
 		return nil
 	}
 	var s []Statement
-	s = append(s, newStatement(0, 1, "$L (", groupName))
+	s = append(s, generated(newStatement(0, 1, "$L (", groupName))...)
 	for _, v := range vars {
-		s = append(s, v.GetStatements()...)
+		s = append(s, nested(v, v.GetStatements())...)
 	}
-	s = append(s, newStatement(-1, 0, ")"))
+	s = append(s, generated(newStatement(-1, 0, ")"))...)
 	return s
 }
 
 // Variable represents a variable, with name, type, and value.
 type Variable struct {
 	Identifier
-	Comment  string
-	Value    Statement
-	Constant bool
-	InGroup  bool
+	Comment     string
+	LineComment string // LineComment is written at the end of the variable's line
+	Value       Statement
+	Constant    bool
+	InGroup     bool
 }
 
 var _ CodeBlock = (*Variable)(nil)
 
 // GetImports returns a slice of imports that this variable and its value uses.
 func (v *Variable) GetImports() []Import {
-	return v.Type.GetImports()
+	return append(getImports(v.Type), statementImports([]Statement{v.Value})...)
 }
 
 // GetStatements returns Value.GetStatements() with the first
@@ -112,19 +133,29 @@ func (v *Variable) GetImports() []Import {
 func (v *Variable) GetStatements() []Statement {
 	var s []Statement
 	s = append(s, Comment(v.Comment).GetStatements()...)
-	s = append(s, v.statement())
-	return s
+	s = append(s, withLineComment(v.statement(), v.LineComment))
+	return generated(s...)
 }
 
 func (v *Variable) String() string {
-	w := newCodeWriter()
-	for _, s := range v.GetStatements() {
-		w.WriteStatement(s)
-	}
-	return w.String()
+	return mustWriteCodeBlock(v)
+}
+
+// Validate returns an error if the variable's value cannot be written.
+func (v *Variable) Validate() error {
+	_, err := writeCodeBlock(v)
+	return err
 }
 
 func (v *Variable) statement() Statement {
+	// a variable without a type takes the type of its value, and a constant without a type or
+	// value in a group repeats the expression before it, like the values of an iota enum
+	if v.Type == nil {
+		if v.Value.Format == "" {
+			return newStatement(0, 0, "$L$L", v.prefix(), v.Name)
+		}
+		return appendStatements(newStatement(0, 0, "$L$L = ", v.prefix(), v.Name), v.Value)
+	}
 	if v.Value.Format == "" {
 		return newStatement(0, 0, "$L$L $T", v.prefix(), v.Name, v.Type)
 	}
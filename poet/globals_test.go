@@ -281,3 +281,34 @@ func (f *VariablesSuite) TestGroupingEmpty(c *C) {
 
 	c.Assert(actual, Equals, expected)
 }
+
+func (f *VariablesSuite) TestConstantsWithoutTypes(c *C) {
+	expected := "" +
+		"const (\n" +
+		"\ta = iota\n" +
+		"\tb\n" +
+		")\n"
+
+	g := &VariableGrouping{}
+	g.Constant("a", nil, "iota").Constant("b", nil, "")
+
+	c.Assert(g.String(), Equals, expected)
+}
+
+func (f *VariablesSuite) TestVariableGroupingComments(c *C) {
+	expected := "" +
+		"const (\n" +
+		"\t// a is the first constant,\n" +
+		"\t// and b is the second\n" +
+		"\ta int = 1\n" +
+		"\tb int = 2 // the last one\n" +
+		")\n"
+
+	g := &VariableGrouping{}
+	g.Constant("a", Int, "$L", 1).
+		EntryComment("a is the first constant,\nand b is the second").
+		Constant("b", Int, "$L", 2).
+		EntryLineComment("the last one")
+
+	c.Assert(g.String(), Equals, expected)
+}
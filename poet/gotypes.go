@@ -0,0 +1,144 @@
+package poet
+
+import (
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// TypeReferenceFromGoType creates a TypeReference from a type loaded by go/types, such as the
+// types found by golang.org/x/tools/go/packages. Named types are qualified with the package
+// that declares them, using the package's declared name, and instantiated generic types are
+// written with their type arguments.
+func TypeReferenceFromGoType(t types.Type) TypeReference {
+	switch t := t.(type) {
+	case *types.Named:
+		return goNamedTypeReference(t.Obj(), t.TypeArgs())
+	case *types.Alias:
+		return goNamedTypeReference(t.Obj(), nil)
+	case *types.TypeParam:
+		return &TypeParameter{Name: t.Obj().Name()}
+	case *types.Basic:
+		if t.Kind() == types.UnsafePointer {
+			return NewTypeReference("unsafe", "Pointer")
+		}
+		// untyped constants, like the type of 1 in const a = 1, are written with the type they
+		// default to, while untyped nil is written as nil
+		if t.Info()&types.IsUntyped != 0 {
+			return NewTypeReference("", strings.TrimPrefix(types.Default(t).(*types.Basic).Name(), "untyped "))
+		}
+		return NewTypeReference("", t.Name())
+	case *types.Pointer:
+		return PointerTo(TypeReferenceFromGoType(t.Elem()))
+	case *types.Slice:
+		return SliceOf(TypeReferenceFromGoType(t.Elem()))
+	case *types.Array:
+		return ArrayOf(int(t.Len()), TypeReferenceFromGoType(t.Elem()))
+	case *types.Map:
+		return MapOf(TypeReferenceFromGoType(t.Key()), TypeReferenceFromGoType(t.Elem()))
+	case *types.Chan:
+		return ChanOf(goChanDir(t.Dir()), TypeReferenceFromGoType(t.Elem()))
+	case *types.Signature:
+		f := &typeReferenceFuncType{}
+		for i := 0; i < t.Params().Len(); i++ {
+			p := IdentifierParameter{}
+			if t.Variadic() && i == t.Params().Len()-1 {
+				p.Type = TypeReferenceFromGoType(t.Params().At(i).Type().(*types.Slice).Elem())
+				p.Variadic = true
+			} else {
+				p.Type = TypeReferenceFromGoType(t.Params().At(i).Type())
+			}
+			f.Params = append(f.Params, p)
+		}
+		for i := 0; i < t.Results().Len(); i++ {
+			f.Results = append(f.Results, IdentifierParameter{
+				Identifier: Identifier{Type: TypeReferenceFromGoType(t.Results().At(i).Type())},
+			})
+		}
+		return f
+	case *types.Struct:
+		s := NewAnonymousStruct()
+		for i := 0; i < t.NumFields(); i++ {
+			f := t.Field(i)
+			name := f.Name()
+			if f.Embedded() {
+				name = ""
+			}
+			s.FieldWithTag(name, TypeReferenceFromGoType(f.Type()), t.Tag(i))
+		}
+		return s
+	case *types.Interface:
+		i := NewAnonymousInterface()
+		for n := 0; n < t.NumEmbeddeds(); n++ {
+			if union, ok := t.EmbeddedType(n).(*types.Union); ok {
+				i.Union(goUnionTerms(union)...)
+			} else {
+				i.EmbedInterface(TypeReferenceFromGoType(t.EmbeddedType(n)))
+			}
+		}
+		for n := 0; n < t.NumExplicitMethods(); n++ {
+			m := t.ExplicitMethod(n)
+			signature := TypeReferenceFromGoType(m.Type()).(*typeReferenceFuncType)
+			i.Method(&FuncSpec{
+				Name:             m.Name(),
+				Parameters:       signature.Params,
+				ResultParameters: signature.Results,
+			})
+		}
+		return i
+	case *types.Union:
+		return NewAnonymousInterface().Union(goUnionTerms(t)...)
+	}
+
+	return &typeReferenceValue{Name: types.TypeString(t, (*types.Package).Name)}
+}
+
+// goNamedTypeReference creates a TypeReference for a named type, instantiated with the type
+// arguments if there are any.
+func goNamedTypeReference(obj *types.TypeName, args *types.TypeList) TypeReference {
+	var result TypeReference = &typeReferenceValue{Name: obj.Name()}
+
+	// types in the universe scope, like error, have no package
+	if obj.Pkg() != nil {
+		named := &typeReferenceValue{
+			Import: ImportSpecFromGoPackage(obj.Pkg()),
+			Name:   obj.Name(),
+		}
+		if basic, ok := obj.Type().Underlying().(*types.Basic); ok && basic.Info()&types.IsString != 0 {
+			named.kind = reflect.String
+		}
+		result = named
+	}
+
+	if args.Len() == 0 {
+		return result
+	}
+
+	refs := make([]TypeReference, args.Len())
+	for i := range refs {
+		refs[i] = TypeReferenceFromGoType(args.At(i))
+	}
+	return Instantiate(result, refs...)
+}
+
+// goUnionTerms returns the terms of a union, like ~int | string.
+func goUnionTerms(union *types.Union) []TypeReference {
+	terms := make([]TypeReference, union.Len())
+	for i := range terms {
+		terms[i] = TypeReferenceFromGoType(union.Term(i).Type())
+		if union.Term(i).Tilde() {
+			terms[i] = Approx(terms[i])
+		}
+	}
+	return terms
+}
+
+func goChanDir(dir types.ChanDir) reflect.ChanDir {
+	switch dir {
+	case types.SendOnly:
+		return reflect.SendDir
+	case types.RecvOnly:
+		return reflect.RecvDir
+	}
+	return reflect.BothDir
+}
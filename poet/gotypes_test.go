@@ -0,0 +1,146 @@
+package poet
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+
+	. "gopkg.in/check.v1"
+)
+
+type GoTypesSuite struct{}
+
+var _ = Suite(&GoTypesSuite{})
+
+func (s *GoTypesSuite) TestNamedType(c *C) {
+	pkg := types.NewPackage("example.com/clientlib", "client")
+	client := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Client", nil), types.NewStruct(nil, nil), nil)
+
+	typeRef := TypeReferenceFromGoType(types.NewPointer(client))
+	c.Assert(typeRef.GetName(), Equals, "*client.Client")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "example.com/clientlib", Qualified: true, Name: "client"},
+	})
+}
+
+func (s *GoTypesSuite) TestUniverseTypes(c *C) {
+	values := []struct {
+		typ      types.Type
+		expected string
+	}{
+		{types.Typ[types.Int], "int"},
+		{types.Universe.Lookup("byte").Type(), "byte"},
+		{types.Universe.Lookup("error").Type(), "error"},
+		{types.Universe.Lookup("any").Type(), "any"},
+		{types.NewInterfaceType(nil, nil), "interface{}"},
+		{types.Typ[types.UntypedInt], "int"},
+		{types.Typ[types.UntypedFloat], "float64"},
+		{types.Typ[types.UntypedRune], "rune"},
+		{types.Typ[types.UntypedString], "string"},
+		{types.Typ[types.UntypedBool], "bool"},
+		{types.Typ[types.UntypedNil], "nil"},
+	}
+
+	for _, v := range values {
+		typeRef := TypeReferenceFromGoType(v.typ)
+		c.Check(typeRef.GetName(), Equals, v.expected)
+		for _, i := range typeRef.GetImports() {
+			c.Check(i.GetPackage(), Equals, "")
+		}
+	}
+
+	typeRef := TypeReferenceFromGoType(types.Typ[types.UnsafePointer])
+	c.Assert(typeRef.GetName(), Equals, "unsafe.Pointer")
+	c.Assert(typeRef.GetImports()[0].GetPackage(), Equals, "unsafe")
+}
+
+func (s *GoTypesSuite) TestComposedTypes(c *C) {
+	pkg := types.NewPackage("context", "context")
+	ctx := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Context", nil), types.NewInterfaceType(nil, nil), nil)
+	str := types.Typ[types.String]
+	err := types.Universe.Lookup("error").Type()
+
+	params := types.NewTuple(
+		types.NewParam(token.NoPos, nil, "ctx", ctx),
+		types.NewParam(token.NoPos, nil, "keys", types.NewSlice(str)),
+	)
+	results := types.NewTuple(
+		types.NewParam(token.NoPos, nil, "", types.NewMap(str, types.NewArray(types.Universe.Lookup("byte").Type(), 16))),
+		types.NewParam(token.NoPos, nil, "", err),
+	)
+
+	values := []struct {
+		typ      types.Type
+		expected string
+	}{
+		{types.NewSlice(types.NewPointer(ctx)), "[]*context.Context"},
+		{types.NewChan(types.RecvOnly, ctx), "<-chan context.Context"},
+		{types.NewChan(types.SendOnly, str), "chan<- string"},
+		{types.NewChan(types.SendRecv, types.NewChan(types.RecvOnly, str)), "chan (<-chan string)"},
+		{types.NewSignatureType(nil, nil, nil, params, results, false), "func(context.Context, []string) (map[string][16]byte, error)"},
+		{types.NewSignatureType(nil, nil, nil, params, nil, true), "func(context.Context, ...string)"},
+	}
+
+	for _, v := range values {
+		c.Check(TypeReferenceFromGoType(v.typ).GetName(), Equals, v.expected)
+	}
+}
+
+func (s *GoTypesSuite) TestGenericTypes(c *C) {
+	pkg := types.NewPackage("example.com/sets", "sets")
+	t := types.NewTypeParam(types.NewTypeName(token.NoPos, pkg, "T", nil), types.Universe.Lookup("comparable").Type())
+	set := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "Set", nil), nil, nil)
+	set.SetTypeParams([]*types.TypeParam{t})
+	set.SetUnderlying(types.NewStruct(nil, nil))
+
+	c.Assert(TypeReferenceFromGoType(t).GetName(), Equals, "T")
+	c.Assert(TypeReferenceFromGoType(types.NewSlice(t)).GetName(), Equals, "[]T")
+
+	other := types.NewPackage("example.com/other", "other")
+	key := types.NewNamed(types.NewTypeName(token.NoPos, other, "Key", nil), types.Typ[types.String], nil)
+	inst, err := types.Instantiate(nil, set, []types.Type{key}, true)
+	c.Assert(err, IsNil)
+
+	typeRef := TypeReferenceFromGoType(inst)
+	c.Assert(typeRef.GetName(), Equals, "sets.Set[other.Key]")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "example.com/sets", Qualified: true},
+		&ImportSpec{Package: "example.com/other", Qualified: true},
+	})
+}
+
+func (s *GoTypesSuite) TestCheckedPackage(c *C) {
+	src := "" +
+		"package store\n" +
+		"\n" +
+		"type Item struct{ Name string }\n" +
+		"\n" +
+		"func Lookup(items map[string]*Item, keys ...string) ([]Item, bool) { return nil, false }\n"
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "store.go", src, 0)
+	c.Assert(err, IsNil)
+	conf := types.Config{}
+	pkg, err := conf.Check("example.com/store", fset, []*ast.File{file}, nil)
+	c.Assert(err, IsNil)
+
+	lookup := pkg.Scope().Lookup("Lookup")
+	typeRef := TypeReferenceFromGoType(lookup.Type())
+	c.Assert(typeRef.GetName(), Equals, "func(map[string]*store.Item, ...string) ([]store.Item, bool)")
+
+	fspec := NewFileSpec("main")
+	fspec.CodeBlock(NewFuncSpec("find").Parameter("lookup", typeRef))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, ""+
+		"package main\n"+
+		"\n"+
+		"import (\n"+
+		"\t\"example.com/store\"\n"+
+		")\n"+
+		"\n"+
+		"func find(lookup func(map[string]*store.Item, ...string) ([]store.Item, bool)) {\n"+
+		"}\n")
+}
@@ -0,0 +1,101 @@
+package poet
+
+import (
+	"sort"
+	"strings"
+)
+
+// ImportLayout describes how a FileSpec arranges its import block. Imports are split into
+// groups which are written in the order below, separated by a blank line:
+//
+//	standard library packages
+//	third-party packages
+//	local packages, whose path starts with one of LocalPrefixes
+//	blank imports included for their side effects
+//
+// Within each group imports are sorted by package path, then by alias.
+type ImportLayout struct {
+	LocalPrefixes []string // LocalPrefixes are package path prefixes, like goimports' -local flag
+}
+
+const (
+	importGroupStandard = iota
+	importGroupThirdParty
+	importGroupLocal
+	importGroupBlank
+	importGroupCount
+)
+
+// GetStatements returns the import block for the given imports, or nil if there are none.
+func (l ImportLayout) GetStatements(imports []Import) []Statement {
+	if len(imports) == 0 {
+		return nil
+	}
+
+	groups := make([][]Import, importGroupCount)
+	for _, i := range imports {
+		g := l.group(i)
+		groups[g] = append(groups[g], i)
+	}
+
+	statements := []Statement{newStatement(0, 1, "import (")}
+	written := false
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		if written {
+			statements = append(statements, Statement{})
+		}
+		sortImports(group)
+		for _, i := range group {
+			var prefix string
+			if i.GetAlias() != "" {
+				prefix = i.GetAlias() + " "
+			}
+			statements = append(statements, newStatement(0, 0, "$L$S", prefix, i.GetPackage()))
+		}
+		written = true
+	}
+	statements = append(statements, newStatement(-1, 0, ")"))
+
+	return statements
+}
+
+// group returns the group the import belongs to.
+func (l ImportLayout) group(i Import) int {
+	pkg := i.GetPackage()
+
+	if importMode(i) == ImportBlank {
+		return importGroupBlank
+	}
+	for _, prefix := range l.LocalPrefixes {
+		if prefix != "" && strings.HasPrefix(pkg, prefix) {
+			return importGroupLocal
+		}
+	}
+	if isStandardPackage(pkg) {
+		return importGroupStandard
+	}
+	return importGroupThirdParty
+}
+
+// isStandardPackage reports whether the package path looks like a standard library package,
+// using the same rule as goimports: the first element of the path does not contain a dot.
+func isStandardPackage(pkg string) bool {
+	first := pkg
+	if ndx := strings.Index(pkg, "/"); ndx >= 0 {
+		first = pkg[:ndx]
+	}
+	return !strings.Contains(first, ".")
+}
+
+// sortImports sorts imports by package path, then by alias.
+func sortImports(imports []Import) {
+	sort.Slice(imports, func(a, b int) bool {
+		if imports[a].GetPackage() != imports[b].GetPackage() {
+			return imports[a].GetPackage() < imports[b].GetPackage()
+		}
+		return imports[a].GetAlias() < imports[b].GetAlias()
+	})
+}
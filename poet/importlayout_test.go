@@ -0,0 +1,70 @@
+package poet
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ImportLayoutSuite struct{}
+
+var _ = Suite(&ImportLayoutSuite{})
+
+func (s *ImportLayoutSuite) TestImportLayoutEmpty(c *C) {
+	c.Assert(ImportLayout{}.GetStatements(nil), IsNil)
+}
+
+func (s *ImportLayoutSuite) TestImportLayoutGroups(c *C) {
+	expected := "" +
+		"import (\n" +
+		"\t\"bytes\"\n" +
+		"\tblah \"bytes\"\n" +
+		"\t\"net/http\"\n" +
+		"\n" +
+		"\t\"github.com/a/b\"\n" +
+		"\t\"golang.org/x/net/context\"\n" +
+		"\n" +
+		"\t\"github.com/mine/pkg/a\"\n" +
+		"\t\"github.com/mine/pkg/b\"\n" +
+		"\n" +
+		"\t_ \"github.com/lib/pq\"\n" +
+		"\t_ \"image/png\"\n" +
+		")\n"
+
+	imports := []Import{
+		&ImportSpec{Package: "github.com/mine/pkg/b"},
+		&ImportSpec{Package: "golang.org/x/net/context"},
+		&ImportSpec{Package: "net/http"},
+		&ImportSpec{Package: "image/png", Alias: "_"},
+		&ImportSpec{Package: "bytes", Alias: "blah"},
+		&ImportSpec{Package: "github.com/mine/pkg/a"},
+		&ImportSpec{Package: "github.com/lib/pq", Alias: "_"},
+		&ImportSpec{Package: "bytes"},
+		&ImportSpec{Package: "github.com/a/b"},
+	}
+	layout := ImportLayout{LocalPrefixes: []string{"github.com/mine/"}}
+
+	w := newCodeWriter()
+	for _, st := range layout.GetStatements(imports) {
+		w.WriteStatement(st)
+	}
+	c.Assert(w.String(), Equals, expected)
+}
+
+func (s *ImportLayoutSuite) TestImportLayoutSingleGroup(c *C) {
+	expected := "" +
+		"import (\n" +
+		"\t\"github.com/a/b\"\n" +
+		")\n"
+
+	w := newCodeWriter()
+	for _, st := range (ImportLayout{}).GetStatements([]Import{&ImportSpec{Package: "github.com/a/b"}}) {
+		w.WriteStatement(st)
+	}
+	c.Assert(w.String(), Equals, expected)
+}
+
+func (s *ImportLayoutSuite) TestIsStandardPackage(c *C) {
+	c.Check(isStandardPackage("fmt"), Equals, true)
+	c.Check(isStandardPackage("net/http"), Equals, true)
+	c.Check(isStandardPackage("golang.org/x/net/context"), Equals, false)
+	c.Check(isStandardPackage("gopkg.in/check.v1"), Equals, false)
+}
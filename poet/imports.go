@@ -2,7 +2,12 @@ package poet
 
 import (
 	"bytes"
+	"fmt"
+	"go/types"
 	"path"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 // ImportSpec implements Import to represent an imported go package
@@ -10,40 +15,109 @@ type ImportSpec struct {
 	Package   string
 	Alias     string
 	Qualified bool
+	Name      string     // Name is the package's name, only needed when it cannot be derived from Package
+	Mode      ImportMode // Mode is how the package is imported, derived from Alias if it is ImportNormal
 }
 
-// getQualifier returns the fully qualified package (e.g. bytes.) for use in a qualified
-// declared type
-func (i *ImportSpec) getQualifier() string {
-	if i == nil || !i.Qualified {
-		return ""
-	}
+// ImportMode is the way a package is imported, which decides how a file refers to it.
+type ImportMode int
 
-	result := bytes.Buffer{}
+const (
+	// ImportNormal imports a package by its name, e.g. import "bytes"
+	ImportNormal ImportMode = iota
+	// ImportAliased imports a package by its Alias, e.g. import b "bytes"
+	ImportAliased
+	// ImportBlank imports a package only for its side effects, e.g. import _ "image/png"
+	ImportBlank
+	// ImportDot imports a package's exported names into the file, e.g. import . "gopkg.in/check.v1",
+	// so they are written without a qualifier
+	ImportDot
+)
 
-	if i.Alias != "" {
-		result.WriteString(i.Alias)
-	} else {
-		// the package may contain slashes, so only write the base name of the package,
-		// not the full package
-		result.WriteString(path.Base(i.Package))
+// NewImportSpec creates a qualified ImportSpec for the package with the given mode. The alias is
+// only used by ImportAliased.
+func NewImportSpec(pkg string, mode ImportMode, alias string) *ImportSpec {
+	i := &ImportSpec{
+		Package:   pkg,
+		Qualified: true,
+		Mode:      mode,
 	}
-	result.WriteString(".")
+	if mode == ImportAliased {
+		i.Alias = alias
+	}
+	return i
+}
 
-	return result.String()
+// ImportSpecFromGoPackage creates a qualified ImportSpec for a package loaded by go/types,
+// recording the package's declared name.
+func ImportSpecFromGoPackage(pkg *types.Package) *ImportSpec {
+	i := &ImportSpec{
+		Package:   pkg.Path(),
+		Qualified: true,
+	}
+	if pkg.Name() != packageNameFromPath(pkg.Path()) {
+		i.Name = pkg.Name()
+	}
+	return i
+}
+
+// getQualifier returns the fully qualified package (e.g. bytes.) for use in a qualified
+// declared type
+func (i *ImportSpec) getQualifier() string {
+	return (*importNames)(nil).qualifier(i)
 }
 
 var _ Import = (*ImportSpec)(nil)
 
-// GetAlias returns the alias associated with the package
+// GetAlias returns the alias associated with the package, which is _ for blank imports and .
+// for dot imports
 func (i *ImportSpec) GetAlias() string {
 	if i == nil {
 		return ""
 	}
 
+	switch i.GetMode() {
+	case ImportBlank:
+		return "_"
+	case ImportDot:
+		return "."
+	}
 	return i.Alias
 }
 
+// GetMode returns how the package is imported. If Mode is ImportNormal, the mode is derived from
+// Alias, so an ImportSpec with the alias _ or . is a blank or dot import.
+func (i *ImportSpec) GetMode() ImportMode {
+	if i == nil {
+		return ImportNormal
+	}
+	if i.Mode != ImportNormal {
+		return i.Mode
+	}
+
+	return aliasMode(i.Alias)
+}
+
+// importMode returns how any Import is imported.
+func importMode(i Import) ImportMode {
+	if spec, ok := i.(*ImportSpec); ok {
+		return spec.GetMode()
+	}
+	return aliasMode(i.GetAlias())
+}
+
+func aliasMode(alias string) ImportMode {
+	switch alias {
+	case "":
+		return ImportNormal
+	case "_":
+		return ImportBlank
+	case ".":
+		return ImportDot
+	}
+	return ImportAliased
+}
+
 // GetPackage returns the package
 func (i *ImportSpec) GetPackage() string {
 	if i == nil {
@@ -52,3 +126,165 @@ func (i *ImportSpec) GetPackage() string {
 
 	return i.Package
 }
+
+// PackageName returns the name of the imported package, which is Name if it is set and
+// otherwise derived from the package path.
+func (i *ImportSpec) PackageName() string {
+	if i == nil {
+		return ""
+	}
+	if i.Name != "" {
+		return i.Name
+	}
+
+	return packageNameFromPath(i.Package)
+}
+
+// packageNameFromPath guesses a package's name from its import path, in the same way as
+// goimports. A trailing major version element (github.com/foo/bar/v2) is skipped, then the
+// name is the last path element up to its first character that cannot be part of an
+// identifier (gopkg.in/check.v1 is check), after removing any "go-" prefix (go-yaml is yaml).
+func packageNameFromPath(pkg string) string {
+	base := path.Base(pkg)
+	if isMajorVersion(base) {
+		if dir := path.Dir(pkg); dir != "." {
+			base = path.Base(dir)
+		}
+	}
+
+	name := strings.TrimPrefix(base, "go-")
+	if ndx := strings.IndexFunc(name, isNotIdentifier); ndx >= 0 {
+		name = name[:ndx]
+	}
+	if name == "" {
+		return base
+	}
+
+	return name
+}
+
+// isMajorVersion reports whether the path element is a module major version suffix, e.g. v2.
+// v0 and v1 never appear as suffixes, so packages named v1 are left alone.
+func isMajorVersion(elem string) bool {
+	if !strings.HasPrefix(elem, "v") {
+		return false
+	}
+	n, err := strconv.Atoi(elem[1:])
+	return err == nil && n >= 2
+}
+
+func isNotIdentifier(r rune) bool {
+	return !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// importNames holds the names a file uses to refer to the packages it imports, so that
+// packages with the same name get distinct qualifiers. A nil *importNames refers to each
+// package by its alias or package name.
+type importNames struct {
+	names       map[string]string // names maps the path of each package imported without an alias to its name
+	self        string            // self is the import path of the file's own package, which is never qualified
+	unqualified bool              // unqualified leaves out every qualifier
+}
+
+// unqualifiedNames writes every type without a qualifier, for Unqualified.
+var unqualifiedNames = &importNames{unqualified: true}
+
+// newImportNames chooses a name for every package imported without an alias, preferring the
+// name given in packageNames over the import's own package name. Explicit aliases are reserved
+// first, then packages are named in the order they are given; a package whose name is already
+// taken is given the name followed by the lowest free number, starting at 2 (e.g. rand2).
+func newImportNames(imports []Import, packageNames map[string]string) *importNames {
+	n := &importNames{
+		names: make(map[string]string),
+	}
+	used := make(map[string]bool)
+
+	for _, i := range imports {
+		if alias := i.GetAlias(); alias != "" {
+			used[alias] = true
+		}
+	}
+
+	for _, i := range imports {
+		pkg := i.GetPackage()
+		if i.GetAlias() != "" || pkg == "" {
+			continue
+		}
+		if _, named := n.names[pkg]; named {
+			continue
+		}
+
+		base := packageNames[pkg]
+		if base == "" {
+			base = importPackageName(i)
+		}
+		name := base
+		for suffix := 2; used[name]; suffix++ {
+			name = fmt.Sprintf("%s%d", base, suffix)
+		}
+		used[name] = true
+		n.names[pkg] = name
+	}
+
+	return n
+}
+
+// importPackageName returns the package name of any Import.
+func importPackageName(i Import) string {
+	if spec, ok := i.(*ImportSpec); ok {
+		return spec.PackageName()
+	}
+	return packageNameFromPath(i.GetPackage())
+}
+
+// qualifier returns the qualifier (e.g. bytes.) used for the import in the file. Dot imports
+// and the file's own package have no qualifier.
+func (n *importNames) qualifier(i *ImportSpec) string {
+	if i == nil || !i.Qualified || (n != nil && (n.unqualified || n.self != "" && i.Package == n.self)) {
+		return ""
+	}
+
+	result := bytes.Buffer{}
+
+	// a blank import can't be referred to, and a dot import is referred to without a qualifier
+	if i.GetMode() == ImportBlank || i.GetMode() == ImportDot {
+		return ""
+	} else if i.GetMode() == ImportAliased && i.Alias != "" {
+		result.WriteString(i.Alias)
+	} else if name, ok := n.lookup(i.Package); ok {
+		result.WriteString(name)
+	} else {
+		result.WriteString(i.PackageName())
+	}
+	result.WriteString(".")
+
+	return result.String()
+}
+
+// lookup returns the name chosen for a package imported without an alias.
+func (n *importNames) lookup(pkg string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	name, ok := n.names[pkg]
+	return name, ok
+}
+
+// aliased returns the imports as they should be written in the import block. A package whose
+// chosen name differs from the last element of its path is given that name as an alias, so the
+// file compiles even if its package name was guessed wrong.
+func (n *importNames) aliased(imports []Import) []Import {
+	result := make([]Import, 0, len(imports))
+	for _, i := range imports {
+		name, ok := n.lookup(i.GetPackage())
+		if i.GetAlias() == "" && ok && name != path.Base(i.GetPackage()) {
+			i = &ImportSpec{
+				Package:   i.GetPackage(),
+				Alias:     name,
+				Qualified: true,
+			}
+		}
+		result = append(result, i)
+	}
+	return result
+}
@@ -1,6 +1,8 @@
 package poet
 
 import (
+	"go/types"
+
 	. "gopkg.in/check.v1"
 )
 
@@ -56,3 +58,131 @@ func (f *ImportsSuite) TestNils(c *C) {
 	c.Assert(nilInst.GetAlias(), Equals, "")
 	c.Assert(nilInst.GetPackage(), Equals, "")
 }
+
+func (f *ImportsSuite) TestImportNames(c *C) {
+	names := newImportNames([]Import{
+		&ImportSpec{Package: "math/rand", Qualified: true},
+		&ImportSpec{Package: "crypto/rand", Qualified: true},
+		&ImportSpec{Package: "github.com/foo/rand", Qualified: true},
+		&ImportSpec{Package: "math/rand", Qualified: true},
+		&ImportSpec{Package: "bytes", Qualified: true, Alias: "rand3"},
+		&ImportSpec{Package: "image/png", Alias: "_"},
+	}, nil)
+
+	c.Check(names.qualifier(&ImportSpec{Package: "math/rand", Qualified: true}), Equals, "rand.")
+	c.Check(names.qualifier(&ImportSpec{Package: "crypto/rand", Qualified: true}), Equals, "rand2.")
+	c.Check(names.qualifier(&ImportSpec{Package: "github.com/foo/rand", Qualified: true}), Equals, "rand4.")
+	c.Check(names.qualifier(&ImportSpec{Package: "bytes", Qualified: true, Alias: "rand3"}), Equals, "rand3.")
+	c.Check(names.qualifier(&ImportSpec{Package: "crypto/rand", Qualified: false}), Equals, "")
+	c.Check(names.qualifier(&ImportSpec{Package: "os", Qualified: true}), Equals, "os.")
+}
+
+func (f *ImportsSuite) TestImportNamesAliased(c *C) {
+	imports := []Import{
+		&ImportSpec{Package: "math/rand", Qualified: true},
+		&ImportSpec{Package: "crypto/rand", Qualified: true},
+		&ImportSpec{Package: "image/png", Alias: "_"},
+	}
+	expected := []Import{
+		imports[0],
+		&ImportSpec{Package: "crypto/rand", Qualified: true, Alias: "rand2"},
+		imports[2],
+	}
+
+	c.Assert(newImportNames(imports, nil).aliased(imports), DeepEquals, expected)
+}
+
+func (f *ImportsSuite) TestImportNamesNil(c *C) {
+	names := (*importNames)(nil)
+	c.Assert(names.qualifier(&ImportSpec{Package: "math/rand", Qualified: true}), Equals, "rand.")
+}
+
+func (f *ImportsSuite) TestPackageNameFromPath(c *C) {
+	for _, test := range []struct {
+		pkg  string
+		name string
+	}{
+		{"bytes", "bytes"},
+		{"net/http", "http"},
+		{"gopkg.in/check.v1", "check"},
+		{"gopkg.in/yaml.v2", "yaml"},
+		{"github.com/foo/bar/v2", "bar"},
+		{"github.com/foo/bar/v10", "bar"},
+		{"k8s.io/api/core/v1", "v1"},
+		{"github.com/go-yaml/yaml", "yaml"},
+		{"github.com/foo/go-bar", "bar"},
+		{"github.com/opentracing/opentracing-go", "opentracing"},
+		{"github.com/foo/bar-baz", "bar"},
+	} {
+		c.Check(packageNameFromPath(test.pkg), Equals, test.name, Commentf("package %s", test.pkg))
+	}
+}
+
+func (f *ImportsSuite) TestImportSpecPackageName(c *C) {
+	c.Check((&ImportSpec{Package: "gopkg.in/check.v1"}).PackageName(), Equals, "check")
+	c.Check((&ImportSpec{Package: "github.com/foo/bar", Name: "baz"}).PackageName(), Equals, "baz")
+	c.Check((*ImportSpec)(nil).PackageName(), Equals, "")
+}
+
+func (f *ImportsSuite) TestQualifiedImportWithVersion(c *C) {
+	imp := &ImportSpec{
+		Package:   "gopkg.in/check.v1",
+		Qualified: true,
+	}
+	c.Assert(imp.getQualifier(), Equals, "check.")
+}
+
+func (f *ImportsSuite) TestImportNamesWithPackageNames(c *C) {
+	imports := []Import{
+		&ImportSpec{Package: "github.com/foo/bar", Qualified: true},
+		&ImportSpec{Package: "github.com/foo/bar/v2", Qualified: true},
+		&ImportSpec{Package: "bytes", Qualified: true},
+	}
+	names := newImportNames(imports, map[string]string{"github.com/foo/bar": "baz"})
+	expected := []Import{
+		&ImportSpec{Package: "github.com/foo/bar", Qualified: true, Alias: "baz"},
+		&ImportSpec{Package: "github.com/foo/bar/v2", Qualified: true, Alias: "bar"},
+		imports[2],
+	}
+
+	c.Check(names.qualifier(imports[0].(*ImportSpec)), Equals, "baz.")
+	c.Check(names.qualifier(imports[1].(*ImportSpec)), Equals, "bar.")
+	c.Check(names.aliased(imports), DeepEquals, expected)
+}
+
+func (f *ImportsSuite) TestImportSpecFromGoPackage(c *C) {
+	c.Check(ImportSpecFromGoPackage(types.NewPackage("bytes", "bytes")), DeepEquals, &ImportSpec{
+		Package:   "bytes",
+		Qualified: true,
+	})
+	c.Check(ImportSpecFromGoPackage(types.NewPackage("github.com/foo/go-client", "fooclient")), DeepEquals, &ImportSpec{
+		Package:   "github.com/foo/go-client",
+		Qualified: true,
+		Name:      "fooclient",
+	})
+}
+
+func (f *ImportsSuite) TestImportModes(c *C) {
+	for _, test := range []struct {
+		imp       *ImportSpec
+		mode      ImportMode
+		alias     string
+		qualifier string
+	}{
+		{NewImportSpec("bytes", ImportNormal, ""), ImportNormal, "", "bytes."},
+		{NewImportSpec("bytes", ImportAliased, "b"), ImportAliased, "b", "b."},
+		{NewImportSpec("image/png", ImportBlank, ""), ImportBlank, "_", ""},
+		{NewImportSpec("gopkg.in/check.v1", ImportDot, ""), ImportDot, ".", ""},
+		{&ImportSpec{Package: "bytes", Qualified: true, Alias: "b"}, ImportAliased, "b", "b."},
+		{&ImportSpec{Package: "image/png", Alias: "_"}, ImportBlank, "_", ""},
+		{&ImportSpec{Package: "gopkg.in/check.v1", Qualified: true, Alias: "."}, ImportDot, ".", ""},
+	} {
+		comment := Commentf("import %#v", test.imp)
+		c.Check(test.imp.GetMode(), Equals, test.mode, comment)
+		c.Check(test.imp.GetAlias(), Equals, test.alias, comment)
+		c.Check(test.imp.getQualifier(), Equals, test.qualifier, comment)
+	}
+
+	c.Check(NewImportSpec("bytes", ImportDot, "b").Alias, Equals, "")
+	c.Check((*ImportSpec)(nil).GetMode(), Equals, ImportNormal)
+}
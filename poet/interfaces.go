@@ -1,5 +1,9 @@
 package poet
 
+import (
+	"strings"
+)
+
 var _ CodeBlock = (*InterfaceSpec)(nil)
 var _ TypeReference = (*InterfaceSpec)(nil)
 
@@ -8,8 +12,11 @@ type InterfaceSpec struct {
 	// CodeBlock
 
 	Name               string
+	Import             *ImportSpec // Import is the package the interface is declared in, or nil for the file's own package
 	Comment            string
-	EmbeddedInterfaces []TypeReference
+	TypeParameters     []*TypeParameter
+	EmbeddedInterfaces []TypeReference   // EmbeddedInterfaces are types, or *EmbeddedInterface for those with comments
+	Unions             [][]TypeReference // Unions are type set elements, each a union of terms like ~int | float64
 	Methods            []*FuncSpec
 }
 
@@ -26,53 +33,150 @@ func (i *InterfaceSpec) Method(spec *FuncSpec) *InterfaceSpec {
 	return i
 }
 
+// TypeParameter adds a type parameter to the interface, making it generic.
+func (i *InterfaceSpec) TypeParameter(p *TypeParameter) *InterfaceSpec {
+	i.TypeParameters = append(i.TypeParameters, p)
+	return i
+}
+
 // EmbedInterface specifies an interface to embed in the interface
 func (i *InterfaceSpec) EmbedInterface(interfaceType TypeReference) *InterfaceSpec {
 	i.EmbeddedInterfaces = append(i.EmbeddedInterfaces, interfaceType)
 	return i
 }
 
+// EmbedInterfaceWithComment specifies an interface to embed in the interface, with a comment
+// above it and a comment at the end of its line. Either comment may be empty.
+func (i *InterfaceSpec) EmbedInterfaceWithComment(interfaceType TypeReference, comment, lineComment string) *InterfaceSpec {
+	i.EmbeddedInterfaces = append(i.EmbeddedInterfaces, &EmbeddedInterface{
+		TypeReference: interfaceType,
+		Comment:       comment,
+		LineComment:   lineComment,
+	})
+	return i
+}
+
+// EmbeddedInterface is an interface embedded in an InterfaceSpec along with its comments, as
+// IdentifierField is for the fields of a struct. It is written as the embedded TypeReference.
+type EmbeddedInterface struct {
+	TypeReference
+	Comment     string
+	LineComment string // LineComment is written at the end of the embedded interface's line
+}
+
+var _ TypeReference = (*EmbeddedInterface)(nil)
+
+// GetImports returns the imports needed to refer to the embedded interface.
+func (e *EmbeddedInterface) GetImports() []Import {
+	return getImports(e.TypeReference)
+}
+
+func (e *EmbeddedInterface) elems() []TypeReference {
+	return []TypeReference{e.TypeReference}
+}
+
+func (e *EmbeddedInterface) getNameIn(names *importNames) string {
+	return typeName(e.TypeReference, names)
+}
+
+// Union adds a type set element to the interface, which restricts it to the union of the given
+// terms. Use Approx for terms matching every type with the given underlying type. A union
+// without terms is left out.
+func (i *InterfaceSpec) Union(terms ...TypeReference) *InterfaceSpec {
+	i.Unions = append(i.Unions, terms)
+	return i
+}
+
 // GetImports returns Imports used by the interface
 func (i *InterfaceSpec) GetImports() []Import {
-	packages := []Import{}
+	packages := typeParameterImports(i.TypeParameters)
 
 	for _, method := range i.Methods {
 		packages = append(packages, method.GetImports()...)
 	}
 
 	for _, embedded := range i.EmbeddedInterfaces {
-		packages = append(packages, embedded.GetImports()...)
+		packages = append(packages, getImports(embedded)...)
+	}
+
+	for _, union := range i.Unions {
+		for _, term := range union {
+			packages = append(packages, getImports(term)...)
+		}
 	}
 
 	return packages
 }
 
-// GetName returns the name and fulfills TypeReference.
+// GetName returns the name, qualified if the interface is declared in a package, and fulfills
+// TypeReference.
 func (i *InterfaceSpec) GetName() string {
-	return i.Name
+	return i.getNameIn(nil)
+}
+
+func (i *InterfaceSpec) getNameIn(names *importNames) string {
+	return names.qualifier(i.Import) + i.Name
+}
+
+func (i *InterfaceSpec) referenceImports() []Import {
+	return []Import{i.Import}
+}
+
+// InPackage declares the interface in the package with the given import path and name, as for
+// StructSpec.InPackage.
+func (i *InterfaceSpec) InPackage(importPath, name string) *InterfaceSpec {
+	i.Import = declaredIn(importPath, name)
+	return i
 }
 
 // String outputs the interface declaration
 func (i *InterfaceSpec) String() string {
-	writer := newCodeWriter()
-	if i.Comment != "" {
-		writer.WriteCodeBlock(Comment(i.Comment))
-	}
-	writer.WriteStatement(newStatement(0, 1, "type $L interface {", i.Name))
+	return mustWriteCodeBlock(i)
+}
+
+// Validate returns an ErrorList of the interface's statements that cannot be written, or nil.
+func (i *InterfaceSpec) Validate() error {
+	_, err := writeCodeBlock(i)
+	return err
+}
+
+// GetStatements returns the interface declaration as statements.
+func (i *InterfaceSpec) GetStatements() []Statement {
+	var statements []Statement
+
+	statements = append(statements, Comment(i.Comment).GetStatements()...)
+	typeParams, args := writeTypeParameters(i.TypeParameters)
+	args = append([]interface{}{i.Name}, args...)
+	statements = append(statements, newStatement(0, 1, "type $L"+typeParams+" interface {", args...))
 
 	for _, interf := range i.EmbeddedInterfaces {
-		writer.WriteStatement(newStatement(0, 0, "$L", interf.GetName()))
+		statement := newStatement(0, 0, "$T", interf)
+		if embedded, ok := interf.(*EmbeddedInterface); ok {
+			statements = append(statements, Comment(embedded.Comment).GetStatements()...)
+			statement = withLineComment(statement, embedded.LineComment)
+		}
+		statements = append(statements, statement)
 	}
 
-	for _, method := range i.Methods {
-		if method.Comment != "" {
-			writer.WriteStatement(newStatement(0, 0, "// $L", method.Comment))
+	for _, union := range i.Unions {
+		if len(union) == 0 {
+			continue
+		}
+		format := strings.TrimSuffix(strings.Repeat("$T | ", len(union)), " | ")
+		args := make([]interface{}, len(union))
+		for n, term := range union {
+			args[n] = term
 		}
+		statements = append(statements, newStatement(0, 0, format, args...))
+	}
+
+	for _, method := range i.Methods {
+		statements = append(statements, Comment(method.Comment).GetStatements()...)
 		signature, args := method.Signature()
-		writer.WriteStatement(newStatement(0, 0, signature, args...))
+		statements = append(statements, withLineComment(newStatement(0, 0, signature, args...), method.LineComment))
 	}
 
-	writer.WriteStatement(newStatement(-1, 0, "}"))
+	statements = append(statements, newStatement(-1, 0, "}"))
 
-	return writer.String()
+	return generated(statements...)
 }
@@ -1,7 +1,9 @@
 package poet
 
 import (
+	"fmt"
 	"io"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -95,3 +97,91 @@ func (f *InterfaceSuite) TestInterfaceImportsFromMethods(c *C) {
 	actual := i.GetImports()
 	c.Assert(actual, DeepEquals, expected)
 }
+
+func (f *InterfaceSuite) TestInterfaceUnion(c *C) {
+	expected := "type Number interface {\n" +
+		"\t~int | ~int64 | float64\n" +
+		"}\n"
+
+	i := NewInterfaceSpec("Number").Union(Approx(Int), Approx(Int64), Float64)
+
+	actual := i.String()
+	c.Assert(actual, Equals, expected)
+}
+
+func (f *InterfaceSuite) TestInterfaceEmptyUnion(c *C) {
+	expected := "type Number interface {\n" +
+		"\t~int\n" +
+		"}\n"
+
+	i := NewInterfaceSpec("Number").Union().Union(Approx(Int))
+
+	c.Assert(i.String(), Equals, expected)
+	c.Assert(NewInterfaceSpec("Empty").Union().String(), Equals, "type Empty interface {\n}\n")
+}
+
+func (f *InterfaceSuite) TestInterfaceUnionWithMethods(c *C) {
+	expected := "type Duration interface {\n" +
+		"\tio.Writer\n" +
+		"\t~int64 | time.Duration\n" +
+		"\t~string\n" +
+		"\tString() string\n" +
+		"}\n"
+
+	i := NewInterfaceSpec("Duration").
+		EmbedInterface(TypeReferenceFromInstance((*io.Writer)(nil))).
+		Union(Approx(Int64), TypeReferenceFromInstance(time.Duration(0))).
+		Union(Approx(String)).
+		Method(NewFuncSpec("String").ResultParameter("", String))
+
+	actual := i.String()
+	c.Assert(actual, Equals, expected)
+}
+
+func (f *InterfaceSuite) TestInterfaceImportsFromUnions(c *C) {
+	expected := []Import{
+		(*ImportSpec)(nil),
+		&ImportSpec{
+			Package:   "time",
+			Qualified: true,
+		},
+	}
+
+	i := NewInterfaceSpec("Duration").Union(Approx(Int64), TypeReferenceFromInstance(time.Duration(0)))
+
+	actual := i.GetImports()
+	c.Assert(actual, DeepEquals, expected)
+}
+
+func (f *InterfaceSuite) TestInterfaceComments(c *C) {
+	expected := "" +
+		"type Store interface {\n" +
+		"\t// Closer releases the store\n" +
+		"\tio.Closer\n" +
+		"\tfmt.Stringer // for logging\n" +
+		"\t// Get returns the value for a key.\n" +
+		"\t//\n" +
+		"\t// It returns nil if the key is missing.\n" +
+		"\tGet(key string) []byte\n" +
+		"\tLen() int // Len is the number of keys\n" +
+		"}\n"
+
+	i := NewInterfaceSpec("Store").
+		EmbedInterfaceWithComment(TypeReferenceFromInstance((*io.Closer)(nil)), "Closer releases the store", "").
+		EmbedInterfaceWithComment(TypeReferenceFromInstance((*fmt.Stringer)(nil)), "", "for logging").
+		Method(NewFuncSpec("Get").
+			FunctionComment("Get returns the value for a key.\n\nIt returns nil if the key is missing.").
+			Parameter("key", String).
+			ResultParameter("", SliceOf(Byte))).
+		Method(NewFuncSpec("Len").FunctionLineComment("Len is the number of\nkeys").ResultParameter("", Int))
+
+	c.Assert(i.String(), Equals, expected)
+	c.Assert(i.EmbeddedInterfaces[1], DeepEquals, &EmbeddedInterface{
+		TypeReference: TypeReferenceFromInstance((*fmt.Stringer)(nil)),
+		LineComment:   "for logging",
+	})
+	c.Assert(i.GetImports()[3:], DeepEquals, []Import{
+		&ImportSpec{Package: "io", Qualified: true},
+		&ImportSpec{Package: "fmt", Qualified: true},
+	})
+}
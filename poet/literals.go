@@ -0,0 +1,330 @@
+package poet
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+
+	mathNaN      = &typeReferenceFunc{Import: &ImportSpec{Package: "math", Qualified: true}, Name: "NaN"}
+	mathInf      = &typeReferenceFunc{Import: &ImportSpec{Package: "math", Qualified: true}, Name: "Inf"}
+	mathCopysign = &typeReferenceFunc{Import: &ImportSpec{Package: "math", Qualified: true}, Name: "Copysign"}
+	timeDate     = &typeReferenceFunc{Import: &ImportSpec{Package: "time", Qualified: true}, Name: "Date"}
+	timeUTC      = &typeReferenceFunc{Import: &ImportSpec{Package: "time", Qualified: true}, Name: "UTC"}
+)
+
+// literal writes a value as a Go expression, naming types as they are named in a file with the
+// given imports.
+func literal(names *importNames, v interface{}) (string, error) {
+	w := &literalWriter{names: names}
+	return w.write(reflect.ValueOf(v), true)
+}
+
+// literalImports returns the imports used by the Go expression for a value.
+func literalImports(v interface{}) []Import {
+	w := &literalWriter{}
+	w.write(reflect.ValueOf(v), true)
+	return w.imports
+}
+
+// literalWriter writes values as Go expressions, collecting the imports of the types it names.
+//
+// Values are written either typed, where the expression must carry its type because it is
+// assigned to an interface or written on its own, or untyped, where the type is known from
+// the enclosing composite literal and an untyped constant is enough.
+type literalWriter struct {
+	names    *importNames
+	imports  []Import
+	pointers []uintptr // pointers are the pointers being written, to detect cycles
+}
+
+func (l *literalWriter) write(v reflect.Value, typed bool) (string, error) {
+	if !v.IsValid() {
+		return "nil", nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return l.convert(v, typed, strconv.FormatBool(v.Bool())), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return l.convert(v, typed, strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return l.convert(v, typed, strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		return l.writeFloat(v, typed), nil
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		bitSize := v.Type().Bits() / 2
+		code := fmt.Sprintf("complex(%s, %s)", l.float(real(c), bitSize), l.float(imag(c), bitSize))
+		return l.convert(v, typed, code), nil
+	case reflect.String:
+		return l.convert(v, typed, strconv.Quote(v.String())), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return "nil", nil
+		}
+		return l.write(v.Elem(), true)
+	case reflect.Ptr:
+		return l.writePointer(v, typed)
+	case reflect.Slice:
+		if v.IsNil() {
+			return l.writeNil(v, typed)
+		}
+		return l.writeElements(v)
+	case reflect.Array:
+		return l.writeElements(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return l.writeNil(v, typed)
+		}
+		return l.writeMap(v)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return l.writeTime(v.Interface().(time.Time)), nil
+		}
+		return l.writeStruct(v)
+	case reflect.Chan:
+		if v.IsNil() {
+			return l.writeNil(v, typed)
+		}
+	case reflect.Func:
+		if v.IsNil() && !typed {
+			return "nil", nil
+		}
+	}
+
+	return "", fmt.Errorf("$V cannot write a %s as a literal", v.Type())
+}
+
+// convert wraps the code of a basic value in a conversion to the value's type, if the value
+// is typed and the code's default type is not the value's type.
+func (l *literalWriter) convert(v reflect.Value, typed bool, code string) string {
+	if !typed || isDefaultType(v.Type()) {
+		return code
+	}
+	return l.typeName(v.Type()) + "(" + code + ")"
+}
+
+// isDefaultType reports whether an untyped constant of the type's kind has the type by default.
+func isDefaultType(t reflect.Type) bool {
+	if t.PkgPath() != "" {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Float64, reflect.Complex128, reflect.String:
+		return true
+	}
+	return false
+}
+
+func (l *literalWriter) writeFloat(v reflect.Value, typed bool) string {
+	f := v.Float()
+	code := l.float(f, v.Type().Bits())
+
+	// math functions return float64, so any other type needs a conversion
+	if math.IsNaN(f) || math.IsInf(f, 0) || (f == 0 && math.Signbit(f)) {
+		if v.Type().Kind() == reflect.Float64 && v.Type().PkgPath() == "" {
+			return code
+		}
+		return l.typeName(v.Type()) + "(" + code + ")"
+	}
+
+	return l.convert(v, typed, code)
+}
+
+// float returns the code for a float, always written so that it is a floating-point constant.
+func (l *literalWriter) float(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return l.ref(mathNaN) + "()"
+	case math.IsInf(f, 1):
+		return l.ref(mathInf) + "(1)"
+	case math.IsInf(f, -1):
+		return l.ref(mathInf) + "(-1)"
+	case f == 0 && math.Signbit(f):
+		return l.ref(mathCopysign) + "(0, -1)"
+	}
+
+	code := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(code, ".e") {
+		code += ".0"
+	}
+	return code
+}
+
+func (l *literalWriter) writeNil(v reflect.Value, typed bool) (string, error) {
+	if !typed {
+		return "nil", nil
+	}
+	return "(" + l.typeName(v.Type()) + ")(nil)", nil
+}
+
+func (l *literalWriter) writePointer(v reflect.Value, typed bool) (string, error) {
+	if v.IsNil() {
+		return l.writeNil(v, typed)
+	}
+
+	for _, p := range l.pointers {
+		if p == v.Pointer() {
+			return "", fmt.Errorf("$V cannot write the cyclic value at %s", v.Type())
+		}
+	}
+	l.pointers = append(l.pointers, v.Pointer())
+	defer func() { l.pointers = l.pointers[:len(l.pointers)-1] }()
+
+	elem := v.Elem()
+	code, err := l.write(elem, true)
+	if err != nil {
+		return "", err
+	}
+
+	switch elem.Kind() {
+	case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+		if elem.Type() != timeType {
+			return "&" + code, nil
+		}
+	}
+	// only composite literals are addressable, so wrap anything else in a slice
+	return "&[]" + l.typeName(elem.Type()) + "{" + code + "}[0]", nil
+}
+
+func (l *literalWriter) writeElements(v reflect.Value) (string, error) {
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		typ := l.typeName(v.Type())
+		if v.Type() == bytesType {
+			typ = "[]byte"
+		}
+		return l.writeBytes(v, typ), nil
+	}
+
+	b := bytes.Buffer{}
+	b.WriteString(l.typeName(v.Type()))
+
+	b.WriteString("{")
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		code, err := l.write(v.Index(i), false)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(code)
+	}
+	b.WriteString("}")
+
+	return b.String(), nil
+}
+
+// writeBytes writes a slice or array of bytes, as a string conversion if the bytes are a
+// valid UTF-8 slice and as hexadecimal elements otherwise.
+func (l *literalWriter) writeBytes(v reflect.Value, typ string) string {
+	data := make([]byte, v.Len())
+	for i := range data {
+		data[i] = byte(v.Index(i).Uint())
+	}
+
+	if v.Kind() == reflect.Slice && utf8.Valid(data) {
+		return typ + "(" + strconv.Quote(string(data)) + ")"
+	}
+
+	elems := make([]string, len(data))
+	for i, d := range data {
+		elems[i] = fmt.Sprintf("0x%02x", d)
+	}
+	return typ + "{" + strings.Join(elems, ", ") + "}"
+}
+
+func (l *literalWriter) writeMap(v reflect.Value) (string, error) {
+	type entry struct {
+		key  reflect.Value
+		code string
+	}
+
+	entries := []entry{}
+	for _, key := range v.MapKeys() {
+		keyCode, err := l.write(key, false)
+		if err != nil {
+			return "", err
+		}
+		valueCode, err := l.write(v.MapIndex(key), false)
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, entry{key, keyCode + ": " + valueCode})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].key, entries[j].key
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		}
+		return entries[i].code < entries[j].code
+	})
+
+	codes := make([]string, len(entries))
+	for i, e := range entries {
+		codes[i] = e.code
+	}
+
+	return l.typeName(v.Type()) + "{" + strings.Join(codes, ", ") + "}", nil
+}
+
+func (l *literalWriter) writeStruct(v reflect.Value) (string, error) {
+	fields := []string{}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		if value.IsZero() {
+			continue
+		}
+		if field.PkgPath != "" {
+			return "", fmt.Errorf("$V cannot write the unexported field %s of %s", field.Name, v.Type())
+		}
+
+		code, err := l.write(value, false)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, field.Name+": "+code)
+	}
+
+	return l.typeName(v.Type()) + "{" + strings.Join(fields, ", ") + "}", nil
+}
+
+// writeTime writes a time as a call to time.Date in UTC.
+func (l *literalWriter) writeTime(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%s(%d, %d, %d, %d, %d, %d, %d, %s)", l.ref(timeDate),
+		t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), l.ref(timeUTC))
+}
+
+// typeName returns the name of the type, recording its imports.
+func (l *literalWriter) typeName(t reflect.Type) string {
+	return l.ref(TypeReferenceFromReflectType(t))
+}
+
+// ref returns the name of the type reference, recording its imports.
+func (l *literalWriter) ref(t TypeReference) string {
+	l.imports = append(l.imports, getImports(t)...)
+	return typeName(t, l.names)
+}
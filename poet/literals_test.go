@@ -0,0 +1,181 @@
+package poet
+
+import (
+	"image"
+	"math"
+	"net/url"
+	"regexp"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type LiteralsSuite struct{}
+
+var _ = Suite(&LiteralsSuite{})
+
+func (s *LiteralsSuite) TestLiteralBasicValues(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "nil"},
+		{true, "true"},
+		{1, "1"},
+		{int8(-3), "int8(-3)"},
+		{uint64(7), "uint64(7)"},
+		{'x', "int32(120)"},
+		{"a \"b\"", "\"a \\\"b\\\"\""},
+		{1.5, "1.5"},
+		{float64(2), "2.0"},
+		{float32(0.1), "float32(0.1)"},
+		{1e21, "1e+21"},
+		{complex(1, -2), "complex(1.0, -2.0)"},
+		{complex64(1), "complex64(complex(1.0, 0.0))"},
+	}
+
+	for _, v := range values {
+		actual, err := literal(nil, v.value)
+		c.Check(err, IsNil)
+		c.Check(actual, Equals, v.expected, Commentf("value %#v", v.value))
+	}
+}
+
+func (s *LiteralsSuite) TestLiteralSpecialFloats(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{math.NaN(), "math.NaN()"},
+		{math.Inf(1), "math.Inf(1)"},
+		{math.Inf(-1), "math.Inf(-1)"},
+		{math.Copysign(0, -1), "math.Copysign(0, -1)"},
+		{float32(math.Inf(1)), "float32(math.Inf(1))"},
+		{[]float32{1, float32(math.NaN())}, "[]float32{1.0, float32(math.NaN())}"},
+	}
+
+	for _, v := range values {
+		actual, err := literal(nil, v.value)
+		c.Check(err, IsNil)
+		c.Check(actual, Equals, v.expected, Commentf("value %#v", v.value))
+		c.Check(literalPackages(v.value), DeepEquals, []string{"math"}, Commentf("value %#v", v.value))
+	}
+}
+
+func (s *LiteralsSuite) TestLiteralComposites(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{[]string{"a", "b"}, "[]string{\"a\", \"b\"}"},
+		{[]string{}, "[]string{}"},
+		{[]int(nil), "([]int)(nil)"},
+		{[]interface{}{1, int8(2), "x", nil}, "[]interface{}{1, int8(2), \"x\", nil}"},
+		{map[string]int{"b": 2, "a": 1, "c": 3}, "map[string]int{\"a\": 1, \"b\": 2, \"c\": 3}"},
+		{map[int]bool{10: true, 9: false, -1: true}, "map[int]bool{-1: true, 9: false, 10: true}"},
+		{map[string]interface{}{"a": []int{1}}, "map[string]interface{}{\"a\": []int{1}}"},
+		{[]byte("hello"), "[]byte(\"hello\")"},
+		{[]byte{0xff, 0x00}, "[]byte{0xff, 0x00}"},
+		{[2][2]int{{1, 2}, {3}}, "[2][2]int{[2]int{1, 2}, [2]int{3, 0}}"},
+		{[4]byte{0xde, 0xad}, "[4]uint8{0xde, 0xad, 0x00, 0x00}"},
+	}
+
+	for _, v := range values {
+		actual, err := literal(nil, v.value)
+		c.Check(err, IsNil)
+		c.Check(actual, Equals, v.expected, Commentf("value %#v", v.value))
+	}
+}
+
+func (s *LiteralsSuite) TestLiteralStructsAndPointers(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{image.Point{X: 1, Y: 2}, "image.Point{X: 1, Y: 2}"},
+		{image.Point{}, "image.Point{}"},
+		{&url.URL{Scheme: "https", Host: "example.com"}, "&url.URL{Scheme: \"https\", Host: \"example.com\"}"},
+		{(*url.URL)(nil), "(*url.URL)(nil)"},
+		{[]*image.Point{{Y: 3}, nil}, "[]*image.Point{&image.Point{Y: 3}, nil}"},
+		{image.Rectangle{Max: image.Point{X: 4}}, "image.Rectangle{Max: image.Point{X: 4}}"},
+		{func() *int { i := 3; return &i }(), "&[]int{3}[0]"},
+		{time.Second, "time.Duration(1000000000)"},
+		{[]time.Month{time.March}, "[]time.Month{3}"},
+		{time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC), "time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)"},
+	}
+
+	for _, v := range values {
+		actual, err := literal(nil, v.value)
+		c.Check(err, IsNil)
+		c.Check(actual, Equals, v.expected, Commentf("value %#v", v.value))
+	}
+}
+
+func (s *LiteralsSuite) TestLiteralErrors(c *C) {
+	type node struct {
+		Next *node
+	}
+	cycle := &node{}
+	cycle.Next = cycle
+
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{func() {}, "$V cannot write a func() as a literal"},
+		{make(chan int), "$V cannot write a chan int as a literal"},
+		{[]interface{}{func() {}}, "$V cannot write a func() as a literal"},
+		{*url.User("me"), "$V cannot write the unexported field username of url.Userinfo"},
+		{cycle, "$V cannot write the cyclic value at *poet.node"},
+	}
+
+	for _, v := range values {
+		_, err := literal(nil, v.value)
+		c.Check(err, ErrorMatches, regexp.QuoteMeta(v.expected), Commentf("value %#v", v.value))
+	}
+}
+
+func (s *LiteralsSuite) TestLiteralInFile(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"image\"\n" +
+		"\t\"math\"\n" +
+		")\n" +
+		"\n" +
+		"var points []image.Point = []image.Point{image.Point{X: 1}, image.Point{Y: 2}}\n" +
+		"\n" +
+		"func limit() float64 {\n" +
+		"\treturn math.Inf(1)\n" +
+		"}\n"
+
+	fspec := NewFileSpec("foo")
+	fspec.GlobalVariable("points", TypeReferenceFromInstance([]image.Point{}), "$V", []image.Point{{X: 1}, {Y: 2}})
+	fspec.CodeBlock(NewFuncSpec("limit").
+		ResultParameter("", Float64).
+		Statement("return $V", math.Inf(1)))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (s *LiteralsSuite) TestLiteralValidate(c *C) {
+	f := NewFuncSpec("foo").Statement("x := $V", make(chan int))
+
+	err := f.Validate()
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*\\$V cannot write a chan int as a literal.*")
+}
+
+// literalPackages returns the packages imported by the literal for the value.
+func literalPackages(value interface{}) []string {
+	packages := []string{}
+	for _, i := range literalImports(value) {
+		if i.GetPackage() != "" {
+			packages = append(packages, i.GetPackage())
+		}
+	}
+	return packages
+}
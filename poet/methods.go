@@ -25,18 +25,27 @@ func NewMethodSpec(name, receiverName string, receiver TypeReference) *MethodSpe
 }
 
 func (m *MethodSpec) String() string {
-	writer := newCodeWriter()
+	return mustWriteCodeBlock(m)
+}
+
+// Validate returns an ErrorList of the method's statements that cannot be written, or nil.
+func (m *MethodSpec) Validate() error {
+	_, err := writeCodeBlock(m)
+	return err
+}
+
+// GetStatements returns the method's comment, declaration and body as statements.
+func (m *MethodSpec) GetStatements() []Statement {
+	var statements []Statement
+
+	statements = append(statements, generated(Comment(m.Comment).GetStatements()...)...)
 
 	signature, args := m.Signature()
 	format := fmt.Sprintf("func ($L $T) %s {", signature)
 	args = append([]interface{}{m.ReceiverName, m.Receiver}, args...)
-	writer.WriteStatement(newStatement(0, 1, format, args...))
-
-	for _, st := range m.Statements {
-		writer.WriteStatement(st)
-	}
-
-	writer.WriteStatement(newStatement(-1, 0, "}"))
+	statements = append(statements, generated(newStatement(0, 1, format, args...))...)
+	statements = append(statements, m.Statements...)
+	statements = append(statements, generated(newStatement(-1, 0, "}"))...)
 
-	return writer.String()
+	return statements
 }
@@ -0,0 +1,260 @@
+package poet
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// unparsedTypeReference is a type in the name of a generic type's instance that could not be
+// parsed. It is reported when it is written with $T, since its packages can't be imported.
+type unparsedTypeReference struct {
+	Name string
+}
+
+var _ TypeReference = (*unparsedTypeReference)(nil)
+
+func (t *unparsedTypeReference) GetImports() []Import {
+	return nil
+}
+
+func (t *unparsedTypeReference) GetName() string {
+	return t.Name
+}
+
+// splitTypeArguments splits the name of an instantiated generic type, like Box[int,string],
+// into the name of the generic type and its type arguments.
+func splitTypeArguments(name string) (string, string) {
+	ndx := strings.Index(name, "[")
+	if ndx < 0 || !strings.HasSuffix(name, "]") {
+		return name, ""
+	}
+	return name[:ndx], name[ndx+1 : len(name)-1]
+}
+
+// reflectTypeArguments parses the comma separated type arguments in the name of a generic
+// type's instance. The reflect package writes them with the full path of their package, e.g.
+// Box[gopkg.in/check%2ev1.C], so they are parsed into qualified TypeReferences.
+func reflectTypeArguments(args string) []TypeReference {
+	refs := []TypeReference{}
+	for _, arg := range splitTopLevel(args, ',') {
+		refs = append(refs, reflectTypeName(arg))
+	}
+	return refs
+}
+
+// reflectTypeName parses a type as it is written in the name of a generic type's instance.
+func reflectTypeName(name string) TypeReference {
+	switch {
+	case strings.HasPrefix(name, "*"):
+		return PointerTo(reflectTypeName(name[1:]))
+	case strings.HasPrefix(name, "[]"):
+		return SliceOf(reflectTypeName(name[2:]))
+	case strings.HasPrefix(name, "["):
+		end := strings.Index(name, "]")
+		if n, err := strconv.Atoi(name[1:end]); err == nil {
+			return ArrayOf(n, reflectTypeName(name[end+1:]))
+		}
+	case strings.HasPrefix(name, "map["):
+		if end := closingBracket(name, len("map")); end > 0 {
+			return MapOf(reflectTypeName(name[len("map["):end]), reflectTypeName(name[end+1:]))
+		}
+	case strings.HasPrefix(name, "chan<- "):
+		return ChanOf(reflect.SendDir, reflectTypeName(name[len("chan<- "):]))
+	case strings.HasPrefix(name, "<-chan "):
+		return ChanOf(reflect.RecvDir, reflectTypeName(name[len("<-chan "):]))
+	case strings.HasPrefix(name, "chan "):
+		return ChanOf(reflect.BothDir, reflectTypeName(name[len("chan "):]))
+	case strings.HasPrefix(name, "func("):
+		if f := reflectSignature(name[len("func"):]); f != nil {
+			return f
+		}
+	case strings.HasPrefix(name, "struct {"):
+		if s := reflectStruct(name); s != nil {
+			return s
+		}
+	case strings.HasPrefix(name, "interface {"):
+		if i := reflectInterface(name); i != nil {
+			return i
+		}
+	}
+
+	base, args := splitTypeArguments(name)
+	if base == "" || strings.ContainsAny(base, " (){}[]\"") {
+		return &unparsedTypeReference{Name: name}
+	}
+
+	var result TypeReference = &typeReferenceValue{Name: base}
+	if ndx := strings.LastIndex(base, "."); ndx >= 0 {
+		result = NewTypeReference(unescapePackagePath(base[:ndx]), base[ndx+1:])
+	}
+
+	if args == "" {
+		return result
+	}
+	return Instantiate(result, reflectTypeArguments(args)...)
+}
+
+// reflectSignature parses the parameters and results of a func type, e.g. (int, ...string)
+// error, or returns nil if they can't be parsed.
+func reflectSignature(signature string) *typeReferenceFuncType {
+	end := closingBracket(signature, 0)
+	if end < 0 {
+		return nil
+	}
+
+	f := &typeReferenceFuncType{}
+	for _, param := range splitTopLevel(signature[1:end], ',') {
+		p := IdentifierParameter{}
+		if strings.HasPrefix(param, "...") {
+			p.Variadic = true
+			param = param[len("..."):]
+		}
+		p.Type = reflectTypeName(param)
+		f.Params = append(f.Params, p)
+	}
+
+	results := strings.TrimSpace(signature[end+1:])
+	if strings.HasPrefix(results, "(") && closingBracket(results, 0) == len(results)-1 {
+		results = results[1 : len(results)-1]
+	}
+	for _, result := range splitTopLevel(results, ',') {
+		f.Results = append(f.Results, IdentifierParameter{
+			Identifier: Identifier{Type: reflectTypeName(result)},
+		})
+	}
+
+	return f
+}
+
+// reflectStruct parses a struct type, e.g. struct { Name string "json:\"name\"" }, or returns
+// nil if it can't be parsed.
+func reflectStruct(name string) *AnonymousStruct {
+	body, ok := literalElements(name, "struct")
+	if !ok {
+		return nil
+	}
+
+	s := NewAnonymousStruct()
+	for _, field := range body {
+		tag := ""
+		if ndx := indexTopLevel(field, '"'); ndx >= 0 {
+			unquoted, err := strconv.Unquote(field[ndx:])
+			if err != nil {
+				return nil
+			}
+			tag = unquoted
+			field = strings.TrimSpace(field[:ndx])
+		}
+
+		// an embedded field is written as its type, which never contains a space
+		if ndx := indexTopLevel(field, ' '); ndx >= 0 {
+			s.FieldWithTag(field[:ndx], reflectTypeName(field[ndx+1:]), tag)
+		} else {
+			s.FieldWithTag("", reflectTypeName(field), tag)
+		}
+	}
+	return s
+}
+
+// reflectInterface parses an interface type, e.g. interface { Close() error }, or returns nil
+// if it can't be parsed.
+func reflectInterface(name string) *AnonymousInterface {
+	body, ok := literalElements(name, "interface")
+	if !ok {
+		return nil
+	}
+
+	i := NewAnonymousInterface()
+	for _, method := range body {
+		ndx := strings.Index(method, "(")
+		if ndx <= 0 {
+			return nil
+		}
+		signature := reflectSignature(method[ndx:])
+		if signature == nil {
+			return nil
+		}
+		i.Method(&FuncSpec{
+			Name:             method[:ndx],
+			Parameters:       signature.Params,
+			ResultParameters: signature.Results,
+		})
+	}
+	return i
+}
+
+// literalElements returns the semicolon separated elements of a struct or interface type
+// literal, as the reflect package writes them, e.g. struct { A int; B string }.
+func literalElements(name, keyword string) ([]string, bool) {
+	body := strings.TrimPrefix(name, keyword+" ")
+	if !strings.HasPrefix(body, "{") || closingBracket(body, 0) != len(body)-1 {
+		return nil, false
+	}
+	return splitTopLevel(body[1:len(body)-1], ';'), true
+}
+
+// splitTopLevel splits s at each sep that is not within brackets or a quoted string, trimming
+// the spaces around each part and leaving out empty parts.
+func splitTopLevel(s string, sep byte) []string {
+	parts := []string{}
+	for {
+		ndx := indexTopLevel(s, sep)
+		if ndx < 0 {
+			break
+		}
+		if part := strings.TrimSpace(s[:ndx]); part != "" {
+			parts = append(parts, part)
+		}
+		s = s[ndx+1:]
+	}
+	if part := strings.TrimSpace(s); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// indexTopLevel returns the index of the first c in s that is not within brackets or a quoted
+// string, or -1 if there is none.
+func indexTopLevel(s string, c byte) int {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		if quoted {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '"' {
+				quoted = false
+			}
+			continue
+		}
+		if s[i] == c && depth == 0 {
+			return i
+		}
+		switch s[i] {
+		case '"':
+			quoted = true
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		}
+	}
+	return -1
+}
+
+// closingBracket returns the index of the bracket closing the one at open, or -1 if it is not
+// closed.
+func closingBracket(s string, open int) int {
+	end := indexTopLevel(s[open+1:], closingBrackets[s[open]])
+	if end < 0 {
+		return -1
+	}
+	return open + 1 + end
+}
+
+var closingBrackets = map[byte]byte{
+	'(': ')',
+	'[': ']',
+	'{': '}',
+}
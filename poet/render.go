@@ -0,0 +1,113 @@
+package poet
+
+import (
+	"fmt"
+	"go/scanner"
+	"strings"
+)
+
+// FormatError is returned when generated code cannot be parsed and formatted, which usually
+// means that a Statement's format string does not produce valid Go.
+type FormatError struct {
+	Line   int    // Line is the line of the generated code with the error, starting at 1
+	Column int    // Column is the column of the error within the line, starting at 1
+	Spec   string // Spec describes the CodeBlock that produced the line, e.g. "func foo"
+	Code   string // Code is the generated line with the error
+	Source string // Source is all of the generated code
+	Err    error  // Err is the error reported by go/format
+}
+
+func (e *FormatError) Error() string {
+	msg := e.Err.Error()
+	if list, ok := e.Err.(scanner.ErrorList); ok && len(list) > 0 {
+		msg = list[0].Msg
+	}
+	if e.Line == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf("%d:%d: %s (in %s): %s", e.Line, e.Column, msg, e.Spec, strings.TrimSpace(e.Code))
+}
+
+// newFormatError creates a FormatError for an error returned by go/format for the code written by w.
+func newFormatError(w *codeWriter, code string, err error) *FormatError {
+	e := &FormatError{
+		Source: code,
+		Err:    err,
+	}
+
+	if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+		e.Line = list[0].Pos.Line
+		e.Column = list[0].Pos.Column
+	}
+
+	lines := strings.Split(code, "\n")
+	if e.Line > 0 && e.Line <= len(lines) {
+		e.Code = lines[e.Line-1]
+	}
+	e.Spec = w.SectionAt(e.Line)
+
+	return e
+}
+
+// SpecError describes a spec that cannot be rendered, or a statement within it.
+type SpecError struct {
+	Spec      string // Spec describes the CodeBlock, e.g. "func foo"
+	Statement int    // Statement is the index of the statement among those given by the user, or -1 for one the spec writes itself
+	Format    string // Format is the format string of the statement
+	Err       error
+}
+
+func (e *SpecError) Error() string {
+	if e.Statement < 0 && e.Format != "" {
+		return fmt.Sprintf("%s: %q: %v", e.Spec, e.Format, e.Err)
+	}
+	if e.Statement < 0 {
+		return fmt.Sprintf("%s: %v", e.Spec, e.Err)
+	}
+	return fmt.Sprintf("%s: statement %d (%q): %v", e.Spec, e.Statement, e.Format, e.Err)
+}
+
+// ErrorList is a list of errors, returned when rendering finds more than one problem.
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// describeCodeBlock returns a short description of a CodeBlock for use in error messages.
+func describeCodeBlock(blk CodeBlock) string {
+	switch b := blk.(type) {
+	case *FuncSpec:
+		return "func " + b.Name
+	case *MethodSpec:
+		return "method " + b.Name
+	case *StructSpec:
+		return "struct " + b.Name
+	case *InterfaceSpec:
+		return "interface " + b.Name
+	case *TypeAliasSpec:
+		return "type " + b.Name
+	case *TypeSpec:
+		return "type " + b.Name
+	case *EnumSpec:
+		return "enum " + b.Name
+	case *Variable:
+		if b.Constant {
+			return "const " + b.Name
+		}
+		return "var " + b.Name
+	case *VariableGrouping:
+		return "variable grouping"
+	case Comment:
+		return "comment"
+	case *Code:
+		return "code"
+	}
+
+	return fmt.Sprintf("%T", blk)
+}
@@ -0,0 +1,112 @@
+package poet
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+type RenderSuite struct{}
+
+var _ = Suite(&RenderSuite{})
+
+func (s *RenderSuite) TestDescribeCodeBlock(c *C) {
+	for _, test := range []struct {
+		blk      CodeBlock
+		expected string
+	}{
+		{NewFuncSpec("foo"), "func foo"},
+		{NewMethodSpec("foo", "b", Int), "method foo"},
+		{NewStructSpec("foo"), "struct foo"},
+		{NewInterfaceSpec("foo"), "interface foo"},
+		{NewTypeAliasSpec("foo", Int), "type foo"},
+		{NewTypeSpec("foo", Int), "type foo"},
+		{NewTypeAlias("foo", Int), "type foo"},
+		{NewEnumSpec("foo", Int), "enum foo"},
+		{&Variable{Identifier: Identifier{Name: "foo"}}, "var foo"},
+		{&Variable{Identifier: Identifier{Name: "foo"}, Constant: true}, "const foo"},
+		{&VariableGrouping{}, "variable grouping"},
+		{Comment("foo"), "comment"},
+	} {
+		c.Check(describeCodeBlock(test.blk), Equals, test.expected)
+	}
+}
+
+func (s *RenderSuite) TestFormatErrorWithoutPosition(c *C) {
+	err := newFormatError(newCodeWriter(), "package foo\n", errors.New("failed"))
+	c.Assert(err.Line, Equals, 0)
+	c.Assert(err.Error(), Equals, "failed")
+}
+
+func (s *RenderSuite) TestSpecErrorWithoutStatement(c *C) {
+	err := &SpecError{Spec: "func foo", Statement: -1, Err: errors.New("bad")}
+	c.Assert(err.Error(), Equals, "func foo: bad")
+}
+
+func (s *RenderSuite) TestSpecErrorForGeneratedStatement(c *C) {
+	err := &SpecError{Spec: "var foo", Statement: -1, Format: "var $L $T", Err: errors.New("bad")}
+	c.Assert(err.Error(), Equals, `var foo: "var $L $T": bad`)
+}
+
+func (s *RenderSuite) TestSpecErrorCountsUserStatements(c *C) {
+	fnc := NewFuncSpec("foo").
+		FunctionComment("foo does things\nin two lines").
+		Statement("a()").
+		Statement("$T()", 1)
+
+	err := fnc.Validate().(ErrorList)
+	c.Assert(err, HasLen, 1)
+	c.Assert(err[0].(*SpecError).Statement, Equals, 1)
+	c.Assert(err[0].(*SpecError).Spec, Equals, "func foo")
+}
+
+func (s *RenderSuite) TestSpecErrorInAttachedMethod(c *C) {
+	st := NewStructSpec("Foo").Field("a", nil)
+	bar := st.Method("Bar", "f", true)
+	bar.FunctionComment("Bar does things").
+		Statement("a()").
+		Statement("$T()", 1)
+	baz := st.Method("Baz", "f", true)
+	baz.Statement("$L")
+	st.AttachMethod(bar).AttachMethod(baz)
+
+	err := st.Validate().(ErrorList)
+	c.Assert(err, HasLen, 3)
+	c.Check(err[0].(*SpecError).Spec, Equals, "struct Foo")
+	c.Check(err[0].(*SpecError).Statement, Equals, -1)
+	c.Check(err[1].Error(), Equals, `method Bar: statement 1 ("$T()"): $T must implement TypeReference, got type=int 1`)
+	c.Check(err[2].(*SpecError).Spec, Equals, "method Baz")
+	c.Check(err[2].(*SpecError).Statement, Equals, 0)
+
+	typ := NewTypeSpec("Foo", Int)
+	m := typ.Method("Bar", "f", false)
+	m.Statement("$T()", 1)
+	typ.AttachMethod(m)
+	c.Assert(typ.Validate(), ErrorMatches, `method Bar: statement 0 .*`)
+}
+
+func (s *RenderSuite) TestSpecErrorInGroupedVariable(c *C) {
+	g := &VariableGrouping{}
+	g.Variable("a", Int, "$L", 1).Variable("b", Int, "$D", 1)
+
+	err := g.Validate().(ErrorList)
+	c.Assert(err, HasLen, 1)
+	c.Assert(err[0].(*SpecError).Spec, Equals, "var b")
+}
+
+func (s *RenderSuite) TestFormatErrorInAttachedMethod(c *C) {
+	st := NewStructSpec("Foo")
+	m := st.Method("Bar", "f", true)
+	m.Statement("return )")
+	st.AttachMethod(m)
+	file := NewFileSpec("foo").CodeBlock(st).CodeBlock(NewFuncSpec("baz"))
+
+	_, err := file.Render()
+	c.Assert(err, FitsTypeOf, &FormatError{})
+	c.Assert(err.(*FormatError).Spec, Equals, "method Bar")
+}
+
+func (s *RenderSuite) TestErrorList(c *C) {
+	err := ErrorList{errors.New("a"), errors.New("b")}
+	c.Assert(err.Error(), Equals, "a\nb")
+}
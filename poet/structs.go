@@ -1,11 +1,15 @@
 package poet
 
+import "fmt"
+
 // StructSpec represents a struct
 type StructSpec struct {
-	Name    string
-	Comment string
-	Fields  []IdentifierField
-	Methods []*MethodSpec
+	Name           string
+	Import         *ImportSpec // Import is the package the struct is declared in, or nil for the file's own package
+	Comment        string
+	TypeParameters []*TypeParameter
+	Fields         []IdentifierField
+	Methods        []*MethodSpec
 }
 
 var _ TypeReference = (*StructSpec)(nil)
@@ -20,53 +24,91 @@ func NewStructSpec(name string) *StructSpec {
 
 // GetImports returns a slice of imports needed by this struct
 func (s *StructSpec) GetImports() []Import {
-	imports := []Import{}
+	imports := typeParameterImports(s.TypeParameters)
 
 	for _, f := range s.Fields {
-		imports = append(imports, f.Type.GetImports()...)
+		imports = append(imports, getImports(f.Type)...)
 	}
 
 	return imports
 }
 
-// GetName returns the name of this struct's type
+// GetName returns the name of this struct's type, qualified if it is declared in a package
 func (s *StructSpec) GetName() string {
-	return s.Name
+	return s.getNameIn(nil)
+}
+
+func (s *StructSpec) getNameIn(names *importNames) string {
+	return names.qualifier(s.Import) + s.Name
+}
+
+func (s *StructSpec) referenceImports() []Import {
+	return []Import{s.Import}
+}
+
+// InPackage declares the struct in the package with the given import path and name, so that
+// files in other packages refer to it qualified by the package name and import the package.
+// The name may be empty if it can be derived from the import path.
+func (s *StructSpec) InPackage(importPath, name string) *StructSpec {
+	s.Import = declaredIn(importPath, name)
+	return s
 }
 
 func (s *StructSpec) String() string {
-	writer := newCodeWriter()
+	return mustWriteCodeBlock(s)
+}
 
-	if s.Comment != "" {
-		writer.WriteCodeBlock(Comment(s.Comment))
-	}
+// Validate returns an ErrorList of the statements of this struct and its attached methods
+// that cannot be written, or nil.
+func (s *StructSpec) Validate() error {
+	_, err := writeCodeBlock(s)
+	return err
+}
 
-	writer.WriteStatement(newStatement(0, 1, "type $L struct {", s.Name))
+// GetStatements returns the struct's declaration followed by its attached methods as statements.
+func (s *StructSpec) GetStatements() []Statement {
+	var statements []Statement
+
+	statements = append(statements, Comment(s.Comment).GetStatements()...)
+	typeParams, args := writeTypeParameters(s.TypeParameters)
+	args = append([]interface{}{s.Name}, args...)
+	statements = append(statements, newStatement(0, 1, "type $L"+typeParams+" struct {", args...))
 
 	for _, field := range s.Fields {
-		var format string
+		format := "$L $T"
 		arguments := []interface{}{field.Name, field.Type}
 
+		if field.IsEmbedded() {
+			format = "$T"
+			arguments = arguments[1:]
+		}
+		if field.Type == nil {
+			name := "embedded field"
+			if !field.IsEmbedded() {
+				name = "field " + field.Name
+			}
+			arguments[len(arguments)-1] = errorArgument{fmt.Errorf("%s has no type", name)}
+		}
 		if field.Tag != "" {
-			format = "$L $T `$L`"
+			format += " `$L`"
 			arguments = append(arguments, field.Tag)
-		} else {
-			format = "$L $T"
 		}
 
-		writer.WriteStatement(newStatement(0, 0, format, arguments...))
+		statements = append(statements, Comment(field.Comment).GetStatements()...)
+		statements = append(statements, withLineComment(newStatement(0, 0, format, arguments...), field.LineComment))
 	}
-	writer.WriteStatement(newStatement(-1, 0, "}"))
+	statements = append(statements, newStatement(-1, 0, "}"))
 
 	if len(s.Methods) != 0 {
-		writer.WriteStatement(Statement{})
+		statements = append(statements, Statement{})
 	}
+	statements = generated(statements...)
 
 	for _, method := range s.Methods {
-		writer.WriteCodeBlock(method)
-		writer.WriteStatement(Statement{})
+		statements = append(statements, nested(method, method.GetStatements())...)
+		statements = append(statements, generated(Statement{})...)
 	}
-	return writer.String()
+	return statements
 }
 
 // StructComment adds a comment to this struct.
@@ -75,6 +117,13 @@ func (s *StructSpec) StructComment(comment string) *StructSpec {
 	return s
 }
 
+// TypeParameter adds a type parameter to this struct, making it generic. Methods created from
+// the struct have it as their receiver's type argument, e.g. (s *Set[T]).
+func (s *StructSpec) TypeParameter(p *TypeParameter) *StructSpec {
+	s.TypeParameters = append(s.TypeParameters, p)
+	return s
+}
+
 // Field adds a field to this struct.
 func (s *StructSpec) Field(name string, typeRef TypeReference) *StructSpec {
 	s.Fields = append(s.Fields, IdentifierField{
@@ -98,6 +147,46 @@ func (s *StructSpec) FieldWithTag(name string, typeRef TypeReference, tag string
 	return s
 }
 
+// Embed adds an embedded field to this struct, e.g. sync.Mutex, or *bytes.Buffer when typeRef
+// is a PointerTo another type.
+func (s *StructSpec) Embed(typeRef TypeReference) *StructSpec {
+	return s.FieldWithTag("", typeRef, "")
+}
+
+// EmbedWithTag adds an embedded field to this struct with a tag on the field.
+func (s *StructSpec) EmbedWithTag(typeRef TypeReference, tag string) *StructSpec {
+	return s.FieldWithTag("", typeRef, tag)
+}
+
+// FieldComment adds a comment to the field most recently added to this struct.
+func (s *StructSpec) FieldComment(comment string) *StructSpec {
+	if len(s.Fields) != 0 {
+		s.Fields[len(s.Fields)-1].Comment = comment
+	}
+	return s
+}
+
+// FieldLineComment adds a comment to the end of the line of the field most recently added to
+// this struct.
+func (s *StructSpec) FieldLineComment(comment string) *StructSpec {
+	if len(s.Fields) != 0 {
+		s.Fields[len(s.Fields)-1].LineComment = comment
+	}
+	return s
+}
+
+// EmbeddedFields returns the struct's embedded fields, whose fields and methods are promoted
+// to the struct.
+func (s *StructSpec) EmbeddedFields() []IdentifierField {
+	fields := []IdentifierField{}
+	for _, f := range s.Fields {
+		if f.IsEmbedded() {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
 // MethodFromFunction creates a method from a FuncSpec and adds this struct as the receiver.
 func (s *StructSpec) MethodFromFunction(receiverName string, receiverIsPtr bool, funcSpec *FuncSpec) *MethodSpec {
 	return &MethodSpec{
@@ -121,20 +210,20 @@ func (s *StructSpec) AttachMethod(m *MethodSpec) *StructSpec {
 }
 
 func (s *StructSpec) getTypeReference(isPtr bool) TypeReference {
-	if isPtr {
-		return s.typeReferenceAsPointer()
-	}
-	return s
-}
-
-type structSpecAsPointer struct {
-	StructSpec
+	return receiverTypeReference(s.Name, s.TypeParameters, isPtr)
 }
 
-func (sP *structSpecAsPointer) GetName() string {
-	return "*" + sP.Name
-}
+// receiverTypeReference returns the type of a receiver for methods of the named type, which is
+// instantiated with the type's own parameters if it is generic. Methods are declared in the
+// type's own package, so the receiver is never qualified.
+func receiverTypeReference(name string, params []*TypeParameter, isPtr bool) TypeReference {
+	var receiver TypeReference = NewTypeReference("", name)
+	if len(params) != 0 {
+		receiver = Instantiate(receiver, typeParameterReferences(params)...)
+	}
 
-func (s *StructSpec) typeReferenceAsPointer() TypeReference {
-	return &structSpecAsPointer{*s}
+	if isPtr {
+		return PointerTo(receiver)
+	}
+	return receiver
 }
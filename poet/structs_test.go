@@ -2,6 +2,7 @@ package poet
 
 import (
 	"bytes"
+	"sync"
 
 	. "gopkg.in/check.v1"
 )
@@ -159,3 +160,67 @@ func (s *StructsSuite) TestStructName(c *C) {
 	actual := st.GetName()
 	c.Assert(actual, Equals, expected)
 }
+
+func (s *StructsSuite) TestStructEmbeddedFields(c *C) {
+	expected := "" +
+		"type foo struct {\n" +
+		"\t// Mutex guards the buffer\n" +
+		"\tsync.Mutex\n" +
+		"\t*bytes.Buffer `json:\"-\"`\n" +
+		"\tbar\n" +
+		"\tname string\n" +
+		"}\n"
+
+	bar := NewStructSpec("bar")
+	foo := NewStructSpec("foo").
+		Embed(TypeReferenceFromInstance(sync.Mutex{})).
+		FieldComment("Mutex guards the buffer").
+		EmbedWithTag(TypeReferenceFromInstance(&bytes.Buffer{}), `json:"-"`).
+		Embed(bar).
+		Field("name", String)
+
+	c.Assert(foo.String(), Equals, expected)
+	c.Assert(foo.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "sync", Qualified: true},
+		&ImportSpec{Package: "bytes", Qualified: true},
+		(*ImportSpec)(nil),
+		(*ImportSpec)(nil),
+	})
+}
+
+func (s *StructsSuite) TestStructEmbeddedFieldNames(c *C) {
+	set := NewTypeReference("example.com/sets", "Set")
+	foo := NewStructSpec("foo").
+		Field("name", String).
+		Embed(PointerTo(TypeReferenceFromInstance(bytes.Buffer{}))).
+		Embed(Instantiate(set, String)).
+		Embed(NewStructSpec("bar"))
+
+	names := []string{}
+	for _, f := range foo.EmbeddedFields() {
+		c.Check(f.IsEmbedded(), Equals, true)
+		names = append(names, f.FieldName())
+	}
+	c.Assert(names, DeepEquals, []string{"Buffer", "Set", "bar"})
+	c.Assert(foo.Fields[0].IsEmbedded(), Equals, false)
+	c.Assert(foo.Fields[0].FieldName(), Equals, "name")
+}
+
+func (s *StructsSuite) TestStructFieldComments(c *C) {
+	expected := "" +
+		"type user struct {\n" +
+		"\t// name is the user's full name.\n" +
+		"\t// It may be empty.\n" +
+		"\tname string `json:\"name\"` // from the profile\n" +
+		"\tage int // in years\n" +
+		"}\n"
+
+	st := NewStructSpec("user").
+		FieldWithTag("name", String, `json:"name"`).
+		FieldComment("name is the user's full name.\nIt may be empty.").
+		FieldLineComment("from the profile").
+		Field("age", Int).
+		FieldLineComment("in years")
+
+	c.Assert(st.String(), Equals, expected)
+}
@@ -3,6 +3,7 @@ package poet
 import (
 	"bytes"
 	"fmt"
+	"strings"
 )
 
 const templatingChar = '$'
@@ -12,30 +13,70 @@ const templatingChar = '$'
 // $L replaces with the literal value of the argument (%v).
 // $S replaces with the quoted string value of the argument (%q).
 // $T argument must be a TypeReference; it replaces with the TypeRef's GetName().
+// $V replaces with the argument written as a Go literal, e.g. []string{"a", "b"}.
+// $C argument must have statements, like Code; it replaces with the statements, each on its
+// own line and indented relative to the statement containing them.
+//
+// template panics if the format string and arguments do not match.
 func template(format string, args ...interface{}) string {
+	code, err := templateIn(nil, format, args...)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return code
+}
+
+// templateIn is template, writing $T and $V arguments as they are named in a file with the
+// given imports, and returning an error if the format string and arguments do not match.
+func templateIn(names *importNames, format string, args ...interface{}) (string, error) {
+	return templateIndented(names, 0, format, args...)
+}
+
+// templateIndented is templateIn for a statement written at the given indentation. Lines of
+// code from $C arguments are indented to match the statement, while the rest of the format
+// and arguments, like multi-line raw strings, are written as they are.
+func templateIndented(names *importNames, indent int, format string, args ...interface{}) (string, error) {
 	var buffer bytes.Buffer
 
+	if err := argumentError(args); err != nil {
+		return "", err
+	}
+
 	currentArg := 0
 
 	for i := 0; i < len(format); i++ {
 		if format[i] == templatingChar && i+1 < len(format) {
 			if currentArg+1 > len(args) {
-				panic(fmt.Sprintf("Not enough arguments for format string ('%s'), got %d", format, len(args)))
+				return "", fmt.Errorf("Not enough arguments for format string ('%s'), got %d", format, len(args))
 			}
 
 			a := args[currentArg]
 			switch format[i+1] {
 			case 'L':
 				buffer.WriteString(fmt.Sprintf("%v", a))
-				break
 			case 'S':
 				buffer.WriteString(fmt.Sprintf("%q", fmt.Sprintf("%v", a)))
-				break
 			case 'T':
-				buffer.WriteString(getQualifiedNameFromArg(a))
-				break
+				name, err := getQualifiedNameFromArg(a, names)
+				if err != nil {
+					return "", err
+				}
+				buffer.WriteString(name)
+			case 'V':
+				code, err := literal(names, a)
+				if err != nil {
+					return "", err
+				}
+				buffer.WriteString(code)
+			case 'C':
+				code, err := getCodeFromArg(a, names, indent)
+				if err != nil {
+					return "", err
+				}
+				buffer.WriteString(code)
 			default:
-				panic(fmt.Sprintf("Unrecognized templating character in format string ('%s')", format))
+				return "", fmt.Errorf("Unrecognized templating character in format string ('%s')", format)
 			}
 
 			currentArg++
@@ -45,14 +86,67 @@ func template(format string, args ...interface{}) string {
 		}
 	}
 
-	return buffer.String()
+	return buffer.String(), nil
 }
 
-func getQualifiedNameFromArg(obj interface{}) string {
+// argumentError returns the error of the first errorArgument in args, or nil if there is none.
+func argumentError(args []interface{}) error {
+	for _, a := range args {
+		if arg, ok := a.(errorArgument); ok {
+			return arg.err
+		}
+	}
+	return nil
+}
+
+func getQualifiedNameFromArg(obj interface{}, names *importNames) (string, error) {
 	typeRef, ok := obj.(TypeReference)
 	if !ok {
-		panic(fmt.Sprintf("$T must implement TypeReference, got type=%T %#v", obj, obj))
+		return "", fmt.Errorf("$T must implement TypeReference, got type=%T %#v", obj, obj)
+	}
+	if err := checkTypeReference(typeRef); err != nil {
+		return "", err
 	}
+	// the package of a blank import has no name in the file, so its types can't be referred to
+	for _, i := range getImports(typeRef) {
+		if i != nil && importMode(i) == ImportBlank {
+			return "", fmt.Errorf("$T %q refers to the blank import of %q", typeRef.GetName(), i.GetPackage())
+		}
+	}
+
+	return typeName(typeRef, names), nil
+}
+
+// getCodeFromArg writes the statements of the argument at the given indentation. The first
+// line is not indented, since it continues the line of the statement containing it.
+func getCodeFromArg(obj interface{}, names *importNames, indent int) (string, error) {
+	block, ok := obj.(statementBlock)
+	if !ok {
+		return "", fmt.Errorf("$C must have statements, got type=%T %#v", obj, obj)
+	}
+
+	w := newCodeWriter()
+	w.names = names
+	w.currentIndent = indent
+	for _, s := range block.GetStatements() {
+		w.WriteStatement(s)
+	}
+	if len(w.errs) != 0 {
+		return "", w.errs[0].(*SpecError).Err
+	}
+
+	code := strings.TrimPrefix(w.String(), strings.Repeat("\t", indent))
+	return strings.TrimSuffix(code, "\n"), nil
+}
 
-	return typeRef.GetName()
+// templateVerbs returns the templating character of each argument in the format string.
+func templateVerbs(format string) []byte {
+	verbs := []byte{}
+	for i := 0; i+1 < len(format); i++ {
+		if format[i] == templatingChar {
+			verbs = append(verbs, format[i+1])
+			i++
+		}
+	}
+	return verbs
 }
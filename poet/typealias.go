@@ -4,8 +4,11 @@ var _ CodeBlock = (*TypeAliasSpec)(nil)
 var _ TypeReference = (*TypeAliasSpec)(nil)
 
 // TypeAliasSpec represents a type alias. *AliasSpec implements CodeBlock and TypeReference.
+// Despite its name it writes a type definition, type X Y; use TypeSpec to write a true alias,
+// type X = Y, or to attach methods to the type.
 type TypeAliasSpec struct {
 	Name           string
+	Import         *ImportSpec // Import is the package the type is declared in, or nil for the file's own package
 	UnderlyingType TypeReference
 	Comment        string
 }
@@ -24,22 +27,47 @@ func (a *TypeAliasSpec) AliasComment(comment string) *TypeAliasSpec {
 	return a
 }
 
-// GetName returns the alias for this Type Alias.
+// GetName returns the alias for this Type Alias, qualified if it is declared in a package.
 func (a *TypeAliasSpec) GetName() string {
-	return a.Name
+	return a.getNameIn(nil)
+}
+
+func (a *TypeAliasSpec) getNameIn(names *importNames) string {
+	return names.qualifier(a.Import) + a.Name
+}
+
+func (a *TypeAliasSpec) referenceImports() []Import {
+	return []Import{a.Import}
+}
+
+// InPackage declares the type in the package with the given import path and name, as for
+// StructSpec.InPackage.
+func (a *TypeAliasSpec) InPackage(importPath, name string) *TypeAliasSpec {
+	a.Import = declaredIn(importPath, name)
+	return a
 }
 
 // GetImports returns a slice of imports that the aliased type requires.
 func (a *TypeAliasSpec) GetImports() []Import {
-	return a.UnderlyingType.GetImports()
+	return getImports(a.UnderlyingType)
 }
 
 func (a *TypeAliasSpec) String() string {
-	writer := newCodeWriter()
-	if a.Comment != "" {
-		writer.WriteCodeBlock(Comment(a.Comment))
-	}
-	writer.WriteStatement(newStatement(0, 0, "type $T $T", a, a.UnderlyingType))
+	return mustWriteCodeBlock(a)
+}
+
+// Validate returns an error if the type alias cannot be written.
+func (a *TypeAliasSpec) Validate() error {
+	_, err := writeCodeBlock(a)
+	return err
+}
+
+// GetStatements returns the type alias declaration as statements.
+func (a *TypeAliasSpec) GetStatements() []Statement {
+	var statements []Statement
+
+	statements = append(statements, Comment(a.Comment).GetStatements()...)
+	statements = append(statements, newStatement(0, 0, "type $L $T", a.Name, a.UnderlyingType))
 
-	return writer.String()
+	return generated(statements...)
 }
@@ -2,14 +2,16 @@ package poet
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"runtime"
 	"strings"
 )
 
 // UnqualifiedPrefix The prefix for type aliases that will be interpreted as unqualified
+//
+// Deprecated: use Unqualified, which works for any TypeReference and doesn't import the package.
 const UnqualifiedPrefix = "_unqualified"
 
 var (
@@ -52,7 +54,7 @@ var (
 	// Rune A TypeReference for rune
 	Rune = TypeReferenceFromInstanceWithCustomName(int32(0), "rune")
 	// Error A TypeReference for error
-	Error = TypeReferenceFromInstanceWithCustomName(errors.New(""), "error")
+	Error = TypeReferenceFromReflectType(reflect.TypeOf((*error)(nil)).Elem())
 )
 
 // TypeReferenceFromInstance creates a TypeReference from an instance of a variable
@@ -78,6 +80,204 @@ func TypeReferenceFromInstanceWithCustomName(t interface{}, name string) TypeRef
 	return typeRef
 }
 
+// NewTypeReference creates a TypeReference for the named type declared in the package with the
+// given import path, without needing an instance of the type. The package doesn't have to
+// exist yet, so it can refer to code that is being generated. An empty pkgPath refers to a
+// predeclared type, or a type in the file's own package.
+func NewTypeReference(pkgPath, name string) TypeReference {
+	result := &typeReferenceValue{
+		Name: name,
+	}
+	if pkgPath != "" {
+		result.Import = &ImportSpec{
+			Package:   pkgPath,
+			Qualified: true,
+		}
+	}
+	return result
+}
+
+// NewTypeReferenceWithImport creates a TypeReference for the named type in the given package,
+// imported the way the ImportSpec says, e.g. with a dot so the type is written without a
+// qualifier.
+func NewTypeReferenceWithImport(imp *ImportSpec, name string) TypeReference {
+	return &typeReferenceValue{
+		Import: imp,
+		Name:   name,
+	}
+}
+
+// PointerTo creates a TypeReference for a pointer to the given type, e.g. *bytes.Buffer. Like
+// the other helpers below, it works with any TypeReference, including specs like StructSpec.
+func PointerTo(t TypeReference) TypeReference {
+	return &typeReferenceElem{
+		prefix: "*",
+		Elem:   t,
+	}
+}
+
+// SliceOf creates a TypeReference for a slice of the given type, e.g. []string.
+func SliceOf(t TypeReference) TypeReference {
+	return &typeReferenceElem{
+		prefix: "[]",
+		Elem:   t,
+	}
+}
+
+// ArrayOf creates a TypeReference for an array of length n of the given type, e.g. [16]byte.
+func ArrayOf(n int, t TypeReference) TypeReference {
+	return &typeReferenceElem{
+		prefix: fmt.Sprintf("[%d]", n),
+		Elem:   t,
+	}
+}
+
+// MapOf creates a TypeReference for a map from the key type to the value type.
+func MapOf(key, value TypeReference) TypeReference {
+	return &typeReferenceMap{
+		KeyType:   key,
+		ValueType: value,
+	}
+}
+
+// ChanOf creates a TypeReference for a channel of the given type and direction, e.g.
+// ChanOf(reflect.RecvDir, Int) for <-chan int.
+func ChanOf(dir reflect.ChanDir, t TypeReference) TypeReference {
+	return &typeReferenceElem{
+		prefix: dir.String() + " ",
+		Elem:   t,
+	}
+}
+
+// getImports returns the imports needed to refer to a type, or nil if the type is missing. A
+// missing type is reported when the spec using it is written.
+func getImports(t TypeReference) []Import {
+	if t == nil {
+		return nil
+	}
+	if declared, ok := t.(declaredType); ok {
+		return declared.referenceImports()
+	}
+	return t.GetImports()
+}
+
+// isStringType reports whether the underlying type of t is string, like the underlying type of
+// a TypeSpec declaring type Name string.
+func isStringType(t TypeReference) bool {
+	switch t := t.(type) {
+	case nil:
+		return false
+	case *typeReferenceValue:
+		return t.prefix == "" && (t.kind == reflect.String || t.Import == nil && t.Name == "string")
+	case *typeReferenceWithCustomName:
+		return isStringType(t.TypeReference)
+	case *TypeSpec:
+		return isStringType(t.UnderlyingType)
+	case *TypeAliasSpec:
+		return isStringType(t.UnderlyingType)
+	case *EnumSpec:
+		return t.isString()
+	}
+	return t.GetName() == "string"
+}
+
+// declaredType is implemented by specs that declare a type. Their GetImports returns the imports
+// of the declaration, while referring to the type only needs the package it is declared in.
+type declaredType interface {
+	referenceImports() []Import
+}
+
+// declaredIn returns the ImportSpec for a spec declared in the package with the given import
+// path and name. The name may be empty if it can be derived from the path.
+func declaredIn(importPath, name string) *ImportSpec {
+	i := &ImportSpec{
+		Package:   importPath,
+		Qualified: true,
+	}
+	if name != packageNameFromPath(importPath) {
+		i.Name = name
+	}
+	return i
+}
+
+// fileTypeReference is implemented by TypeReferences whose name depends on the names a file
+// uses for its imports.
+type fileTypeReference interface {
+	getNameIn(names *importNames) string
+}
+
+// typeName returns the name of the type as written in a file with the given imports, or the
+// empty string if the type is missing. A missing type is reported by checkTypeReference when
+// the type is written with $T.
+func typeName(t TypeReference, names *importNames) string {
+	if t == nil {
+		return ""
+	}
+	if ref, ok := t.(fileTypeReference); ok {
+		return ref.getNameIn(names)
+	}
+	return t.GetName()
+}
+
+// composedTypeReference is implemented by TypeReferences that are made up of other types, like
+// a pointer to a type.
+type composedTypeReference interface {
+	elems() []TypeReference
+}
+
+// checkTypeReference returns an error if the type, or any of the types it is made up of, is
+// missing, like the element type of PointerTo(nil), or could not be parsed from a reflect name.
+func checkTypeReference(t TypeReference) error {
+	if unparsed, ok := t.(*unparsedTypeReference); ok {
+		return fmt.Errorf("$T cannot parse the type argument %q", unparsed.Name)
+	}
+
+	composed, ok := t.(composedTypeReference)
+	if !ok {
+		return nil
+	}
+
+	for _, elem := range composed.elems() {
+		if elem == nil {
+			return fmt.Errorf("$T is missing a type in %q", t.GetName())
+		}
+		if err := checkTypeReference(elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unqualified creates a TypeReference for t that is written without package qualifiers and
+// imports nothing, e.g. Unqualified(TypeReferenceFromInstance(map[string]*bytes.Buffer{})) is
+// map[string]*Buffer. It is useful for types that the file gets by other means, like a dot
+// import added to the file by hand.
+func Unqualified(t TypeReference) TypeReference {
+	return &typeReferenceUnqualified{Elem: t}
+}
+
+type typeReferenceUnqualified struct {
+	Elem TypeReference
+}
+
+var _ TypeReference = (*typeReferenceUnqualified)(nil)
+
+func (t *typeReferenceUnqualified) GetImports() []Import {
+	return nil
+}
+
+func (t *typeReferenceUnqualified) GetName() string {
+	return typeName(t.Elem, unqualifiedNames)
+}
+
+func (t *typeReferenceUnqualified) getNameIn(names *importNames) string {
+	return t.GetName()
+}
+
+func (t *typeReferenceUnqualified) elems() []TypeReference {
+	return []TypeReference{t.Elem}
+}
+
 type typeReferenceWithCustomName struct {
 	TypeReference
 	name string
@@ -93,13 +293,54 @@ func newTypeReferenceFromInstance(t interface{}, alias string) TypeReference {
 		panic("Invalid nil instance without associated type")
 	}
 
-	if reflectType.Kind() == reflect.Func {
+	// an instance of a named func type refers to the type rather than the function
+	if reflectType.Kind() == reflect.Func && reflectType.Name() == "" {
 		return newTypeReferenceFromFunction(t, alias)
 	}
 
 	return newTypeReferenceFromValue(t, alias)
 }
 
+// typeReferenceElem is a type composed of another TypeReference, like an array of the type.
+type typeReferenceElem struct {
+	prefix string
+	Elem   TypeReference
+}
+
+var _ TypeReference = (*typeReferenceElem)(nil)
+
+func (t *typeReferenceElem) GetImports() []Import {
+	return getImports(t.Elem)
+}
+
+func (t *typeReferenceElem) GetName() string {
+	return t.getNameIn(nil)
+}
+
+func (t *typeReferenceElem) elems() []TypeReference {
+	return []TypeReference{t.Elem}
+}
+
+func (t *typeReferenceElem) getNameIn(names *importNames) string {
+	elem := typeName(t.Elem, names)
+
+	// chan <-chan T would be read as chan<- chan T
+	if t.prefix == "chan " && strings.HasPrefix(elem, "<-") {
+		elem = "(" + elem + ")"
+	}
+
+	return t.prefix + elem
+}
+
+// typeNames returns the comma separated names of the types.
+func typeNames(types []TypeReference, names *importNames) string {
+	result := make([]string, len(types))
+	for i, t := range types {
+		result[i] = typeName(t, names)
+	}
+	return strings.Join(result, ", ")
+}
+
 type typeReferenceMap struct {
 	KeyType   TypeReference
 	ValueType TypeReference
@@ -112,8 +353,8 @@ func newTypeReferenceFromMap(t interface{}, prefix string) TypeReference {
 	refType := reflect.TypeOf(t)
 
 	return &typeReferenceMap{
-		KeyType:   newTypeReferenceFromInstance(reflect.New(refType.Key()).Elem().Interface(), ""),
-		ValueType: newTypeReferenceFromInstance(reflect.New(refType.Elem()).Elem().Interface(), ""),
+		KeyType:   TypeReferenceFromReflectType(refType.Key()),
+		ValueType: TypeReferenceFromReflectType(refType.Elem()),
 		prefix:    prefix,
 	}
 }
@@ -121,19 +362,28 @@ func newTypeReferenceFromMap(t interface{}, prefix string) TypeReference {
 func (t *typeReferenceMap) GetImports() []Import {
 	imports := []Import{}
 
-	imports = append(imports, t.KeyType.GetImports()...)
-	imports = append(imports, t.ValueType.GetImports()...)
+	imports = append(imports, getImports(t.KeyType)...)
+	imports = append(imports, getImports(t.ValueType)...)
 	return imports
 }
 
+func (t *typeReferenceMap) elems() []TypeReference {
+	return []TypeReference{t.KeyType, t.ValueType}
+}
+
 func (t *typeReferenceMap) GetName() string {
-	return fmt.Sprintf("%smap[%s]%s", t.prefix, t.KeyType.GetName(), t.ValueType.GetName())
+	return t.getNameIn(nil)
+}
+
+func (t *typeReferenceMap) getNameIn(names *importNames) string {
+	return fmt.Sprintf("%smap[%s]%s", t.prefix, typeName(t.KeyType, names), typeName(t.ValueType, names))
 }
 
 type typeReferenceValue struct {
 	Import *ImportSpec
 	Name   string
 	prefix string
+	kind   reflect.Kind // kind is the kind of the type's underlying type, if it is known
 }
 
 var _ TypeReference = (*typeReferenceValue)(nil)
@@ -144,35 +394,145 @@ func newTypeReferenceFromValue(t interface{}, alias string) TypeReference {
 
 	result.prefix, refType = dereferenceType("", refType)
 
-	switch refType.Kind() {
-	case reflect.Interface:
-		fallthrough
-	case reflect.Struct:
+	if refType.Name() == "" {
+		switch refType.Kind() {
+		case reflect.Interface, reflect.Struct:
+			literal := TypeReferenceFromReflectType(refType)
+			if result.prefix != "" {
+				literal = &typeReferenceElem{prefix: result.prefix, Elem: literal}
+			}
+			return literal
+		case reflect.Map:
+			return newTypeReferenceFromMap(reflect.New(refType).Elem().Interface(), result.prefix)
+		}
+	}
+
+	return newNamedTypeReference(refType, result.prefix, alias)
+}
+
+// newNamedTypeReference creates a TypeReference for a named type, with the given prefix. An
+// instance of a generic type is written with its type arguments.
+func newNamedTypeReference(refType reflect.Type, prefix string, alias string) TypeReference {
+	name, args := splitTypeArguments(refType.Name())
+	result := &typeReferenceValue{
+		Name: strings.TrimPrefix(name, UnqualifiedPrefix),
+		kind: refType.Kind(),
+	}
+
+	// any named type outside the universe scope, whatever its kind, belongs to a package
+	if refType.PkgPath() != "" {
 		result.Import = &ImportSpec{
 			Qualified: !strings.HasPrefix(refType.Name(), UnqualifiedPrefix),
 			Package:   refType.PkgPath(),
 			Alias:     alias,
+			Name:      reflectPackageName(refType),
 		}
+	}
+
+	if args == "" {
+		result.prefix = prefix
+		return result
+	}
+
+	instance := Instantiate(result, reflectTypeArguments(args)...)
+	if prefix != "" {
+		return &typeReferenceElem{prefix: prefix, Elem: instance}
+	}
+	return instance
+}
+
+// unescapePackagePath unescapes the dots and other special characters that the runtime escapes
+// in the last element of a package path, e.g. gopkg.in/check%2ev1.
+func unescapePackagePath(pkg string) string {
+	if unescaped, err := url.PathUnescape(pkg); err == nil {
+		return unescaped
+	}
+	return pkg
+}
+
+// TypeReferenceFromReflectType creates a TypeReference from a reflect.Type. Unlike
+// TypeReferenceFromInstance it needs no value of the type, so interface types can be referred
+// to directly with reflect.TypeOf((*io.Reader)(nil)).Elem().
+func TypeReferenceFromReflectType(t reflect.Type) TypeReference {
+	if t.Name() != "" {
+		return newNamedTypeReference(t, "", "")
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return PointerTo(TypeReferenceFromReflectType(t.Elem()))
+	case reflect.Slice:
+		return SliceOf(TypeReferenceFromReflectType(t.Elem()))
+	case reflect.Array:
+		return ArrayOf(t.Len(), TypeReferenceFromReflectType(t.Elem()))
 	case reflect.Map:
-		return newTypeReferenceFromMap(reflect.New(refType).Elem().Interface(), result.prefix)
+		return MapOf(TypeReferenceFromReflectType(t.Key()), TypeReferenceFromReflectType(t.Elem()))
+	case reflect.Chan:
+		return ChanOf(t.ChanDir(), TypeReferenceFromReflectType(t.Elem()))
+	case reflect.Func:
+		return funcTypeFromReflectType(t)
+	case reflect.Struct:
+		s := NewAnonymousStruct()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := f.Name
+			if f.Anonymous {
+				name = ""
+			}
+			s.FieldWithTag(name, TypeReferenceFromReflectType(f.Type), string(f.Tag))
+		}
+		return s
+	case reflect.Interface:
+		i := NewAnonymousInterface()
+		for n := 0; n < t.NumMethod(); n++ {
+			m := t.Method(n)
+			signature := funcTypeFromReflectType(m.Type).(*typeReferenceFuncType)
+			i.Method(&FuncSpec{
+				Name:             m.Name,
+				Parameters:       signature.Params,
+				ResultParameters: signature.Results,
+			})
+		}
+		return i
 	}
 
-	result.Name = strings.TrimPrefix(refType.Name(), UnqualifiedPrefix)
+	return &typeReferenceValue{Name: t.String()}
+}
 
-	return result
+// reflectPackageName returns the name of the package declaring a named type, if it differs
+// from the name derived from the package's path.
+func reflectPackageName(refType reflect.Type) string {
+	qualified := refType.String()
+	ndx := strings.Index(qualified, ".")
+	if refType.PkgPath() == "" || ndx < 0 {
+		return ""
+	}
+
+	name := qualified[:ndx]
+	if name == packageNameFromPath(refType.PkgPath()) {
+		return ""
+	}
+	return name
 }
 
+// dereferenceType strips pointer, slice, array and channel types from refType, returning the
+// prefix they are written with. Named types are not stripped, since they are written by name.
 func dereferenceType(prefix string, refType reflect.Type) (string, reflect.Type) {
 	for {
-		if refType.Kind() == reflect.Ptr {
+		if refType.Name() != "" {
+			break
+		} else if refType.Kind() == reflect.Ptr {
 			refType = refType.Elem()
 			// interfaces are already pointers, so don't need to add prefix
 			if refType.Kind() != reflect.Interface {
 				prefix += "*"
 			}
-		} else if refType.Kind() == reflect.Slice || refType.Kind() == reflect.Array {
+		} else if refType.Kind() == reflect.Slice {
 			prefix += "[]"
 			refType = refType.Elem()
+		} else if refType.Kind() == reflect.Array {
+			prefix += fmt.Sprintf("[%d]", refType.Len())
+			refType = refType.Elem()
 		} else if refType.Kind() == reflect.Chan {
 			prefix += refType.ChanDir().String() + " "
 			refType = refType.Elem()
@@ -189,10 +549,14 @@ func (t *typeReferenceValue) GetImports() []Import {
 }
 
 func (t *typeReferenceValue) GetName() string {
+	return t.getNameIn(nil)
+}
+
+func (t *typeReferenceValue) getNameIn(names *importNames) string {
 	result := bytes.Buffer{}
 
 	result.WriteString(t.prefix)
-	result.WriteString(t.Import.getQualifier())
+	result.WriteString(names.qualifier(t.Import))
 	result.WriteString(t.Name)
 
 	return result.String()
@@ -205,18 +569,38 @@ type typeReferenceFunc struct {
 
 var _ TypeReference = (*typeReferenceFunc)(nil)
 
+// newTypeReferenceFromFunction creates a TypeReference for a top-level function. Any other func
+// value, like a nil func, an anonymous func or a method value, has no name that can be referred
+// to, so the TypeReference is for its func type instead.
 func newTypeReferenceFromFunction(t interface{}, alias string) TypeReference {
-	// split up the function's name from its package path
-	n := runtime.FuncForPC(reflect.ValueOf(t).Pointer()).Name()
-	ndxOfLastDot := strings.LastIndex(n, ".")
+	v := reflect.ValueOf(t)
+	if v.IsNil() {
+		return funcTypeFromReflectType(v.Type())
+	}
+
+	// split up the function's name from its package path, e.g. github.com/foo/bar.Baz
+	n := runtime.FuncForPC(v.Pointer()).Name()
+	ndxOfLastSlash := strings.LastIndex(n, "/") + 1
+	ndxOfDot := strings.Index(n[ndxOfLastSlash:], ".")
+	if ndxOfDot < 0 {
+		return funcTypeFromReflectType(v.Type())
+	}
+	ndxOfDot += ndxOfLastSlash
+
+	// closures (Foo.func1), method values (T.Foo-fm) and generic functions (Foo[...]) all
+	// have names that are not identifiers
+	name := n[ndxOfDot+1:]
+	if strings.IndexFunc(name, isNotIdentifier) >= 0 {
+		return funcTypeFromReflectType(v.Type())
+	}
 
 	return &typeReferenceFunc{
 		Import: &ImportSpec{
 			Qualified: true,
-			Package:   n[:ndxOfLastDot],
+			Package:   unescapePackagePath(n[:ndxOfDot]),
 			Alias:     alias,
 		},
-		Name: n[ndxOfLastDot+1:],
+		Name: name,
 	}
 }
 
@@ -225,9 +609,13 @@ func (t *typeReferenceFunc) GetImports() []Import {
 }
 
 func (t *typeReferenceFunc) GetName() string {
+	return t.getNameIn(nil)
+}
+
+func (t *typeReferenceFunc) getNameIn(names *importNames) string {
 	result := bytes.Buffer{}
 
-	result.WriteString(t.Import.getQualifier())
+	result.WriteString(names.qualifier(t.Import))
 	result.WriteString(t.Name)
 
 	return result.String()
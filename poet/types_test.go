@@ -3,8 +3,16 @@ package poet
 import (
 	"bytes"
 	"fmt"
+	htmltemplate "html/template"
 	IoAlias "io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
 
 	"golang.org/x/net/context"
 	. "gopkg.in/check.v1"
@@ -30,6 +38,65 @@ func (s *TypeSuite) TestFunctionRefFromExternal(c *C) {
 	c.Assert(actual, Equals, expected)
 }
 
+func (s *TypeSuite) TestFunctionRefFromEscapedPath(c *C) {
+	typeRef := TypeReferenceFromInstance(Commentf)
+
+	c.Assert(typeRef.GetName(), Equals, "check.Commentf")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{
+			Package:   "gopkg.in/check.v1",
+			Qualified: true,
+		},
+	})
+}
+
+type testBox[T any] struct{}
+
+type testPair[K comparable, V any] struct{}
+
+func (s *TypeSuite) TestGenericInstance(c *C) {
+	poet := "github.com/dpolansky/go-poet/poet"
+	for _, test := range []struct {
+		instance interface{}
+		name     string
+		imports  []string
+	}{
+		{testBox[int]{}, "poet.testBox[int]", []string{poet}},
+		{&testBox[*bytes.Buffer]{}, "*poet.testBox[*bytes.Buffer]", []string{poet, "bytes"}},
+		{testBox[C]{}, "poet.testBox[check.C]", []string{poet, "gopkg.in/check.v1"}},
+		{testBox[testBox[[]C]]{}, "poet.testBox[poet.testBox[[]check.C]]", []string{poet, poet, "gopkg.in/check.v1"}},
+		{testPair[string, map[string][3]*C]{}, "poet.testPair[string, map[string][3]*check.C]", []string{poet, "gopkg.in/check.v1"}},
+		{testBox[chan<- int]{}, "poet.testBox[chan<- int]", []string{poet}},
+		{testBox[any]{}, "poet.testBox[interface{}]", []string{poet}},
+		{testBox[func(*C)]{}, "poet.testBox[func(*check.C)]", []string{poet, "gopkg.in/check.v1"}},
+		{testBox[func(*bytes.Buffer, ...int) (C, error)]{}, "poet.testBox[func(*bytes.Buffer, ...int) (check.C, error)]", []string{poet, "bytes", "gopkg.in/check.v1"}},
+		{testBox[struct {
+			C    *C `json:"c;c"`
+			Func func(C) error
+			bytes.Buffer
+		}]{}, "poet.testBox[struct{ C *check.C `json:\"c;c\"`; Func func(check.C) error; bytes.Buffer }]", []string{poet, "gopkg.in/check.v1", "gopkg.in/check.v1", "bytes"}},
+		{testBox[interface{ Run(*C) error }]{}, "poet.testBox[interface{ Run(*check.C) error }]", []string{poet, "gopkg.in/check.v1"}},
+	} {
+		typeRef := TypeReferenceFromInstance(test.instance)
+		c.Check(typeRef.GetName(), Equals, test.name)
+
+		imports := []string{}
+		for _, i := range typeRef.GetImports() {
+			if i != nil && i.GetPackage() != "" {
+				imports = append(imports, i.GetPackage())
+			}
+		}
+		c.Check(imports, DeepEquals, test.imports, Commentf("%s", test.name))
+	}
+}
+
+func (s *TypeSuite) TestUnparsedTypeArgument(c *C) {
+	typeRef := Instantiate(NewTypeReference("example.com/box", "Box"), reflectTypeName("func(int"))
+
+	fnc := NewFuncSpec("foo").Statement("var a $T", typeRef)
+	c.Assert(fnc.Validate(), ErrorMatches, `func foo: statement 0 .*: \$T cannot parse the type argument "func\(int"`)
+}
+
 func (s *TypeSuite) TestExternalStructPointer(c *C) {
 	expected := "*bytes.Buffer"
 	typeRef := TypeReferenceFromInstance(&bytes.Buffer{})
@@ -62,6 +129,13 @@ func (s *TypeSuite) TestMap(c *C) {
 	c.Assert(actual, Equals, expected)
 }
 
+func (s *TypeSuite) TestMapOfEmptyInterface(c *C) {
+	expected := "map[string]interface{}"
+	typeRef := TypeReferenceFromInstance(map[string]interface{}{})
+
+	c.Assert(typeRef.GetName(), Equals, expected)
+}
+
 func (s *TypeSuite) TestMapPointer(c *C) {
 	expected := "*map[string]string"
 	typeRef := TypeReferenceFromInstance(&map[string]string{})
@@ -101,6 +175,260 @@ func (s *TypeSuite) TestArray(c *C) {
 	c.Assert(actual, Equals, expected)
 }
 
+func (s *TypeSuite) TestFixedSizeArray(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{[16]byte{}, "[16]uint8"},
+		{[2][3]int{}, "[2][3]int"},
+		{[4]*bytes.Buffer{}, "[4]*bytes.Buffer"},
+		{&[4][]string{}, "*[4][]string"},
+		{[0]struct{}{}, "[0]struct{}"},
+	}
+
+	for _, v := range values {
+		c.Check(TypeReferenceFromInstance(v.value).GetName(), Equals, v.expected)
+	}
+}
+
+func (s *TypeSuite) TestArrayOf(c *C) {
+	typeRef := ArrayOf(2, ArrayOf(16, Byte))
+	c.Assert(typeRef.GetName(), Equals, "[2][16]byte")
+
+	typeRef = ArrayOf(4, TypeReferenceFromInstance(&bytes.Buffer{}))
+	c.Assert(typeRef.GetName(), Equals, "[4]*bytes.Buffer")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "bytes", Qualified: true},
+	})
+}
+
+func (s *TypeSuite) TestNamedNonStructTypes(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+		pkg      string
+	}{
+		{time.Duration(0), "time.Duration", "time"},
+		{time.January, "time.Month", "time"},
+		{os.FileMode(0), "fs.FileMode", "io/fs"},
+		{http.StateNew, "http.ConnState", "net/http"},
+		{htmltemplate.HTML(""), "template.HTML", "html/template"},
+		{net.IP{}, "net.IP", "net"},
+		{sort.IntSlice{}, "sort.IntSlice", "sort"},
+		{http.Header{}, "http.Header", "net/http"},
+		{url.Values{}, "url.Values", "net/url"},
+		{http.HandlerFunc(nil), "http.HandlerFunc", "net/http"},
+		{filepath.WalkFunc(nil), "filepath.WalkFunc", "path/filepath"},
+		{new(time.Duration), "*time.Duration", "time"},
+		{[]time.Month{}, "[]time.Month", "time"},
+		{[2]net.IP{}, "[2]net.IP", "net"},
+		{make(chan http.ConnState), "chan http.ConnState", "net/http"},
+	}
+
+	for _, v := range values {
+		typeRef := TypeReferenceFromInstance(v.value)
+		c.Check(typeRef.GetName(), Equals, v.expected)
+		c.Check(typeRef.GetImports(), DeepEquals, []Import{
+			&ImportSpec{Package: v.pkg, Qualified: true},
+		}, Commentf("type %s", v.expected))
+	}
+}
+
+func (s *TypeSuite) TestNamedTypesInMaps(c *C) {
+	typeRef := TypeReferenceFromInstance(map[time.Month]http.Header{})
+
+	c.Assert(typeRef.GetName(), Equals, "map[time.Month]http.Header")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "time", Qualified: true},
+		&ImportSpec{Package: "net/http", Qualified: true},
+	})
+}
+
+func (s *TypeSuite) TestPredeclaredTypesAreUnqualified(c *C) {
+	values := []struct {
+		value    interface{}
+		expected string
+	}{
+		{0, "int"},
+		{"", "string"},
+		{[]float64{}, "[]float64"},
+		{(*error)(nil), "error"},
+		{map[string]bool{}, "map[string]bool"},
+	}
+
+	for _, v := range values {
+		typeRef := TypeReferenceFromInstance(v.value)
+		c.Check(typeRef.GetName(), Equals, v.expected)
+		for _, i := range typeRef.GetImports() {
+			c.Check(i.GetPackage(), Equals, "", Commentf("type %s", v.expected))
+		}
+	}
+}
+
+func (s *TypeSuite) TestNewTypeReference(c *C) {
+	typeRef := NewTypeReference("github.com/foo/generated/v2", "Client")
+	c.Assert(typeRef.GetName(), Equals, "generated.Client")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "github.com/foo/generated/v2", Qualified: true},
+	})
+
+	typeRef = NewTypeReference("", "Local")
+	c.Assert(typeRef.GetName(), Equals, "Local")
+	c.Assert(getImports(typeRef)[0].GetPackage(), Equals, "")
+}
+
+func (s *TypeSuite) TestComposedTypeReferences(c *C) {
+	client := NewTypeReference("example.com/client", "Client")
+	ctx := NewTypeReference("context", "Context")
+
+	values := []struct {
+		typeRef  TypeReference
+		expected string
+	}{
+		{PointerTo(client), "*client.Client"},
+		{SliceOf(PointerTo(client)), "[]*client.Client"},
+		{PointerTo(SliceOf(String)), "*[]string"},
+		{ArrayOf(4, SliceOf(Byte)), "[4][]byte"},
+		{MapOf(String, SliceOf(client)), "map[string][]client.Client"},
+		{MapOf(ArrayOf(2, Int), MapOf(String, Bool)), "map[[2]int]map[string]bool"},
+		{ChanOf(reflect.BothDir, client), "chan client.Client"},
+		{ChanOf(reflect.RecvDir, Int), "<-chan int"},
+		{ChanOf(reflect.SendDir, ChanOf(reflect.RecvDir, Int)), "chan<- <-chan int"},
+		{ChanOf(reflect.BothDir, ChanOf(reflect.RecvDir, Int)), "chan (<-chan int)"},
+		{FuncTypeOf(nil, nil), "func()"},
+		{FuncTypeOf([]TypeReference{ctx, String}, []TypeReference{Error}), "func(context.Context, string) error"},
+		{FuncTypeOf([]TypeReference{Int}, []TypeReference{PointerTo(client), Error}), "func(int) (*client.Client, error)"},
+		{SliceOf(FuncTypeOf(nil, []TypeReference{Bool})), "[]func() bool"},
+	}
+
+	for _, v := range values {
+		c.Check(v.typeRef.GetName(), Equals, v.expected)
+	}
+}
+
+func (s *TypeSuite) TestComposedTypeReferenceImports(c *C) {
+	client := NewTypeReference("example.com/client", "Client")
+	ctx := NewTypeReference("context", "Context")
+
+	typeRef := MapOf(ctx, FuncTypeOf([]TypeReference{SliceOf(client)}, []TypeReference{ChanOf(reflect.RecvDir, PointerTo(client))}))
+	c.Assert(typeRef.GetName(), Equals, "map[context.Context]func([]client.Client) <-chan *client.Client")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "context", Qualified: true},
+		&ImportSpec{Package: "example.com/client", Qualified: true},
+		&ImportSpec{Package: "example.com/client", Qualified: true},
+	})
+}
+
+func (s *TypeSuite) TestComposedTypeReferencesInFile(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\tclient \"example.com/client-go\"\n" +
+		")\n" +
+		"\n" +
+		"var clients map[string]*client.Client\n"
+
+	client := NewTypeReference("example.com/client-go", "Client")
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(&Variable{
+		Identifier: Identifier{Name: "clients", Type: MapOf(String, PointerTo(client))},
+	})
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
+func (s *TypeSuite) TestTypeReferenceFromReflectType(c *C) {
+	values := []struct {
+		typ      reflect.Type
+		expected string
+	}{
+		{reflect.TypeOf((*IoAlias.Reader)(nil)).Elem(), "io.Reader"},
+		{reflect.TypeOf((*IoAlias.Reader)(nil)), "*io.Reader"},
+		{reflect.TypeOf([]*bytes.Buffer{}), "[]*bytes.Buffer"},
+		{reflect.TypeOf(map[string][]time.Month{}), "map[string][]time.Month"},
+		{reflect.TypeOf([3]chan<- int{}), "[3]chan<- int"},
+		{reflect.TypeOf(fmt.Printf), "func(string, ...interface{}) (int, error)"},
+		{reflect.TypeOf(http.NotFound), "func(http.ResponseWriter, *http.Request)"},
+		{reflect.TypeOf((*error)(nil)).Elem(), "error"},
+		{reflect.TypeOf(0), "int"},
+	}
+
+	for _, v := range values {
+		c.Check(TypeReferenceFromReflectType(v.typ).GetName(), Equals, v.expected)
+	}
+
+	typeRef := TypeReferenceFromReflectType(reflect.TypeOf(http.NotFound))
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{Package: "net/http", Qualified: true},
+		&ImportSpec{Package: "net/http", Qualified: true},
+	})
+}
+
+func (s *TypeSuite) TestComposedSpecTypes(c *C) {
+	st := NewStructSpec("Config")
+	iface := NewInterfaceSpec("Reader")
+	alias := NewTypeAliasSpec("ID", String)
+
+	values := []struct {
+		typeRef  TypeReference
+		expected string
+	}{
+		{PointerTo(st), "*Config"},
+		{SliceOf(iface), "[]Reader"},
+		{MapOf(String, PointerTo(alias)), "map[string]*ID"},
+		{MapOf(alias, SliceOf(PointerTo(st))), "map[ID][]*Config"},
+		{ArrayOf(2, ChanOf(reflect.RecvDir, iface)), "[2]<-chan Reader"},
+		{PointerTo(PointerTo(st)), "**Config"},
+		{FuncTypeOf([]TypeReference{PointerTo(st)}, []TypeReference{iface, Error}), "func(*Config) (Reader, error)"},
+	}
+
+	for _, v := range values {
+		c.Check(v.typeRef.GetName(), Equals, v.expected)
+	}
+}
+
+func (s *TypeSuite) TestComposedSpecTypesInFile(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"type Reader interface {\n" +
+		"}\n" +
+		"\n" +
+		"type ID string\n" +
+		"\n" +
+		"type Config struct {\n" +
+		"\treaders map[ID][]Reader\n" +
+		"}\n" +
+		"\n" +
+		"var defaults *Config\n" +
+		"\n" +
+		"func load(ids []*ID) *Config {\n" +
+		"\treturn defaults\n" +
+		"}\n"
+
+	iface := NewInterfaceSpec("Reader")
+	alias := NewTypeAliasSpec("ID", String)
+	st := NewStructSpec("Config").Field("readers", MapOf(alias, SliceOf(iface)))
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(iface)
+	fspec.CodeBlock(alias)
+	fspec.CodeBlock(st)
+	fspec.CodeBlock(&Variable{Identifier: Identifier{Name: "defaults", Type: PointerTo(st)}})
+	fspec.CodeBlock(NewFuncSpec("load").
+		Parameter("ids", SliceOf(PointerTo(alias))).
+		ResultParameter("", PointerTo(st)).
+		Statement("return defaults"))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
 func (s *TypeSuite) TestInterface(c *C) {
 	expected := "os.Signal"
 	typeRef := TypeReferenceFromInstance((*os.Signal)(nil))
@@ -118,6 +446,49 @@ func (s *TypeSuite) TestUnqualified(c *C) {
 	c.Assert(actual, Equals, expected)
 }
 
+func (s *TypeSuite) TestUnqualifiedTypeReference(c *C) {
+	values := []struct {
+		typeRef  TypeReference
+		expected string
+	}{
+		{TypeReferenceFromInstance(&bytes.Buffer{}), "*Buffer"},
+		{TypeReferenceFromInstanceWithAlias(&bytes.Buffer{}, "b"), "*Buffer"},
+		{TypeReferenceFromInstance(map[string][]time.Duration{}), "map[string][]Duration"},
+		{TypeReferenceFromInstance(bytes.NewBuffer), "NewBuffer"},
+		{TypeReferenceFromInstance(func(*http.Request) error { return nil }), "func(*Request) error"},
+		{Instantiate(NewTypeReference("example.com/sets", "Set"), TypeReferenceFromInstance(time.Time{})), "Set[Time]"},
+		{PointerTo(NewStructSpec("User").InPackage("example.com/models", "")), "*User"},
+		{NewAnonymousStruct().Field("At", TypeReferenceFromInstance(time.Time{})), "struct{ At Time }"},
+	}
+
+	for _, v := range values {
+		typeRef := Unqualified(v.typeRef)
+		c.Check(typeRef.GetName(), Equals, v.expected)
+		c.Check(typeRef.GetImports(), IsNil)
+	}
+}
+
+func (s *TypeSuite) TestUnqualifiedTypeReferenceInFile(c *C) {
+	expected := "" +
+		"package foo\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"bytes\"\n" +
+		")\n" +
+		"\n" +
+		"func blah(a *bytes.Buffer, b *Buffer) {\n" +
+		"}\n"
+
+	fspec := NewFileSpec("foo")
+	fspec.CodeBlock(NewFuncSpec("blah").
+		Parameter("a", TypeReferenceFromInstance(&bytes.Buffer{})).
+		Parameter("b", Unqualified(TypeReferenceFromInstanceWithAlias(&bytes.Buffer{}, "b"))))
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}
+
 func (s *TypeSuite) TestChannel(c *C) {
 	expected := "chan *bytes.Buffer"
 	typeRef := TypeReferenceFromInstance(make(chan *bytes.Buffer))
@@ -233,3 +604,42 @@ func (s *TypeSuite) TestTypePrimitives(c *C) {
 		c.Check(test.ref.GetName(), Equals, test.name)
 	}
 }
+
+func (s *TypeSuite) TestErrorHasNoImports(c *C) {
+	for _, i := range Error.GetImports() {
+		c.Check(i.GetPackage(), Equals, "")
+	}
+}
+
+func (s *TypeSuite) TestPackageNameDiffersFromPath(c *C) {
+	typeRef := TypeReferenceFromInstance(&C{})
+	c.Assert(typeRef.GetName(), Equals, "*check.C")
+	c.Assert(typeRef.GetImports(), DeepEquals, []Import{
+		&ImportSpec{
+			Package:   "gopkg.in/check.v1",
+			Qualified: true,
+		},
+	})
+}
+
+func (s *TypeSuite) TestMissingElementTypes(c *C) {
+	for _, typeRef := range []TypeReference{
+		PointerTo(nil),
+		SliceOf(nil),
+		MapOf(String, nil),
+		MapOf(nil, String),
+		Instantiate(nil, Int),
+		Instantiate(NewTypeReference("example.com/box", "Box"), nil),
+		FuncTypeOf([]TypeReference{nil}, nil),
+		PointerTo(SliceOf(nil)),
+		NewAnonymousStruct().Field("a", nil),
+	} {
+		fnc := NewFuncSpec("foo").Parameter("a", typeRef)
+		c.Check(func() { fnc.GetImports() }, Not(Panics), nil)
+		c.Check(fnc.Validate(), ErrorMatches, `func foo: .*\$T is missing a type in .*`, Commentf("%#v", typeRef))
+
+		file := NewFileSpec("example").CodeBlock(NewFuncSpec("bar").Statement("var a $T", typeRef))
+		_, err := file.Render()
+		c.Check(err, NotNil, Commentf("%#v", typeRef))
+	}
+}
@@ -0,0 +1,148 @@
+package poet
+
+var _ CodeBlock = (*TypeSpec)(nil)
+var _ TypeReference = (*TypeSpec)(nil)
+
+// TypeMode is the kind of declaration a TypeSpec writes.
+type TypeMode int
+
+const (
+	// TypeDefinition declares a new named type, e.g. type Status int
+	TypeDefinition TypeMode = iota
+	// TypeAlias declares another name for an existing type, e.g. type Bytes = []byte
+	TypeAlias
+)
+
+// TypeSpec declares a named type with any underlying type, like type Status int or
+// type Handler func(w http.ResponseWriter), along with the methods attached to it. *TypeSpec
+// implements CodeBlock and TypeReference.
+type TypeSpec struct {
+	Name           string
+	Import         *ImportSpec // Import is the package the type is declared in, or nil for the file's own package
+	Mode           TypeMode
+	Comment        string
+	TypeParameters []*TypeParameter
+	UnderlyingType TypeReference
+	Methods        []*MethodSpec
+}
+
+// NewTypeSpec returns a spec declaring a new type with the given underlying type.
+func NewTypeSpec(name string, typeRef TypeReference) *TypeSpec {
+	return &TypeSpec{
+		Name:           name,
+		Mode:           TypeDefinition,
+		UnderlyingType: typeRef,
+	}
+}
+
+// NewTypeAlias returns a spec declaring an alias for the given type.
+func NewTypeAlias(name string, typeRef TypeReference) *TypeSpec {
+	return &TypeSpec{
+		Name:           name,
+		Mode:           TypeAlias,
+		UnderlyingType: typeRef,
+	}
+}
+
+// TypeComment adds a comment to the type.
+func (t *TypeSpec) TypeComment(comment string) *TypeSpec {
+	t.Comment = comment
+	return t
+}
+
+// TypeParameter adds a type parameter to the type, making it generic.
+func (t *TypeSpec) TypeParameter(p *TypeParameter) *TypeSpec {
+	t.TypeParameters = append(t.TypeParameters, p)
+	return t
+}
+
+// InPackage declares the type in the package with the given import path and name, as for
+// StructSpec.InPackage.
+func (t *TypeSpec) InPackage(importPath, name string) *TypeSpec {
+	t.Import = declaredIn(importPath, name)
+	return t
+}
+
+// MethodFromFunction creates a method from a FuncSpec with this type as the receiver.
+func (t *TypeSpec) MethodFromFunction(receiverName string, receiverIsPtr bool, funcSpec *FuncSpec) *MethodSpec {
+	return &MethodSpec{
+		FuncSpec:     *funcSpec,
+		ReceiverName: receiverName,
+		Receiver:     receiverTypeReference(t.Name, t.TypeParameters, receiverIsPtr),
+	}
+}
+
+// Method creates a new method spec with this type as the receiver.
+func (t *TypeSpec) Method(name, receiverName string, receiverIsPtr bool) *MethodSpec {
+	return NewMethodSpec(name, receiverName, receiverTypeReference(t.Name, t.TypeParameters, receiverIsPtr))
+}
+
+// AttachMethod attaches a MethodSpec to the type, so that it is written after the type's
+// declaration. Methods can only be attached to an alias of a type declared in the same package.
+func (t *TypeSpec) AttachMethod(m *MethodSpec) *TypeSpec {
+	t.Methods = append(t.Methods, m)
+	return t
+}
+
+// GetName returns the name of the type, qualified if it is declared in a package.
+func (t *TypeSpec) GetName() string {
+	return t.getNameIn(nil)
+}
+
+func (t *TypeSpec) getNameIn(names *importNames) string {
+	return names.qualifier(t.Import) + t.Name
+}
+
+func (t *TypeSpec) referenceImports() []Import {
+	return []Import{t.Import}
+}
+
+// GetImports returns the imports used by the underlying type, the type parameters and the
+// attached methods.
+func (t *TypeSpec) GetImports() []Import {
+	imports := typeParameterImports(t.TypeParameters)
+	imports = append(imports, getImports(t.UnderlyingType)...)
+	for _, m := range t.Methods {
+		imports = append(imports, m.GetImports()...)
+	}
+	return imports
+}
+
+func (t *TypeSpec) String() string {
+	return mustWriteCodeBlock(t)
+}
+
+// Validate returns an ErrorList of the statements of the type and its attached methods that
+// cannot be written, or nil.
+func (t *TypeSpec) Validate() error {
+	_, err := writeCodeBlock(t)
+	return err
+}
+
+// GetStatements returns the type's declaration followed by its attached methods as statements.
+func (t *TypeSpec) GetStatements() []Statement {
+	var statements []Statement
+
+	statements = append(statements, Comment(t.Comment).GetStatements()...)
+	typeParams, args := writeTypeParameters(t.TypeParameters)
+	args = append([]interface{}{t.Name}, args...)
+	args = append(args, t.UnderlyingType)
+	format := "type $L" + typeParams + " $T"
+	if t.Mode == TypeAlias {
+		format = "type $L" + typeParams + " = $T"
+	}
+	statements = append(statements, newStatement(0, 0, format, args...))
+
+	if len(t.Methods) != 0 {
+		statements = append(statements, Statement{})
+	}
+	statements = generated(statements...)
+
+	for i, method := range t.Methods {
+		if i > 0 {
+			statements = append(statements, generated(Statement{})...)
+		}
+		statements = append(statements, nested(method, method.GetStatements())...)
+	}
+	return statements
+}
@@ -0,0 +1,84 @@
+package poet
+
+import (
+	"net/http"
+
+	. "gopkg.in/check.v1"
+)
+
+type TypeSpecSuite struct{}
+
+var _ = Suite(&TypeSpecSuite{})
+
+func (s *TypeSpecSuite) TestTypeDefinition(c *C) {
+	c.Assert(NewTypeSpec("Status", Int).String(), Equals, "type Status int\n")
+	c.Assert(NewTypeSpec("IDs", SliceOf(String)).TypeComment("IDs are user IDs").String(), Equals, ""+
+		"// IDs are user IDs\n"+
+		"type IDs []string\n")
+}
+
+func (s *TypeSpecSuite) TestTypeAlias(c *C) {
+	t := NewTypeParameter("T", Any)
+	c.Assert(NewTypeAlias("Bytes", SliceOf(Byte)).String(), Equals, "type Bytes = []byte\n")
+	c.Assert(NewTypeAlias("List", SliceOf(t)).TypeParameter(t).String(), Equals, "type List[T any] = []T\n")
+}
+
+func (s *TypeSpecSuite) TestTypeWithMethods(c *C) {
+	expected := "" +
+		"type Handler func(w http.ResponseWriter, r *http.Request)\n" +
+		"\n" +
+		"func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {\n" +
+		"\th(w, r)\n" +
+		"}\n" +
+		"\n" +
+		"func (h *Handler) Reset() {\n" +
+		"\t*h = nil\n" +
+		"}\n"
+
+	w := TypeReferenceFromInstance((*http.ResponseWriter)(nil))
+	r := TypeReferenceFromInstance(&http.Request{})
+	handler := NewTypeSpec("Handler", FuncTypeFromParameters([]IdentifierParameter{
+		{Identifier: Identifier{Name: "w", Type: w}},
+		{Identifier: Identifier{Name: "r", Type: r}},
+	}, nil))
+	serve := handler.Method("ServeHTTP", "h", false)
+	serve.Parameter("w", w).Parameter("r", r).Statement("h(w, r)")
+	handler.AttachMethod(serve)
+	handler.AttachMethod(handler.MethodFromFunction("h", true, NewFuncSpec("Reset").Statement("*h = nil")))
+
+	c.Assert(handler.String(), Equals, expected)
+	c.Assert(handler.GetImports()[0], DeepEquals, &ImportSpec{Package: "net/http", Qualified: true})
+}
+
+func (s *TypeSpecSuite) TestTypeAsTypeReference(c *C) {
+	expected := "" +
+		"package api\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"example.com/app/models\"\n" +
+		")\n" +
+		"\n" +
+		"type Level int\n" +
+		"\n" +
+		"func (l Level) Enabled(s models.Status) bool {\n" +
+		"\treturn int(l) <= int(s)\n" +
+		"}\n" +
+		"\n" +
+		"var levels map[models.Status][]Level\n"
+
+	status := NewTypeSpec("Status", Int).InPackage("example.com/app/models", "")
+	c.Assert(status.GetName(), Equals, "models.Status")
+
+	level := NewTypeSpec("Level", Int)
+	enabled := level.Method("Enabled", "l", false)
+	enabled.Parameter("s", status).ResultParameter("", Bool).Statement("return int(l) <= int(s)")
+	level.AttachMethod(enabled)
+
+	fspec := NewFileSpec("api")
+	fspec.CodeBlock(level)
+	fspec.GlobalVariable("levels", MapOf(status, SliceOf(level)), "")
+
+	actual, err := fspec.Render()
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, expected)
+}